@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bedminer1/liquidity_tracker/internal/backtest"
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetBacktest(c echo.Context) error {
+	asset, start, end, intervalLength, intervals, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if intervals <= 0 {
+		intervals = 7
+	}
+	trainWindow, err := intQueryParam(c, "train_window", 60)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	stride, err := intQueryParam(c, "stride", intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	step := intervalStep(intervalLength)
+	records = stats.Resample(records, step)
+
+	// Every model sees the same resampled history, all registered models by default
+	var modelNames []string
+	for _, name := range strings.Split(c.QueryParam("models"), ",") {
+		if name != "" {
+			modelNames = append(modelNames, name)
+		}
+	}
+	if len(modelNames) == 0 {
+		for _, info := range forecast.Available() {
+			modelNames = append(modelNames, info.Name)
+		}
+	}
+
+	scorecards, err := backtest.Run(records, modelNames, backtest.Config{
+		TrainWindow: trainWindow,
+		Horizon:     intervals,
+		Stride:      stride,
+		Step:        step,
+	})
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"asset":        asset,
+		"train_window": trainWindow,
+		"horizon":      intervals,
+		"stride":       stride,
+		"scorecards":   scorecards,
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bedminer1/liquidity_tracker/internal/correlation"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetCorrelations(c echo.Context) error {
+	_, start, end, intervalLength, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	var assets []string
+	for _, asset := range strings.Split(c.QueryParam("assets"), ",") {
+		if asset = strings.TrimSpace(asset); asset != "" {
+			assets = append(assets, asset)
+		}
+	}
+	if len(assets) < 2 {
+		return c.JSON(400, echo.Map{
+			"error": "'assets' must list at least 2 comma-separated assets",
+		})
+	}
+
+	cfg := correlation.Config{Step: intervalStep(intervalLength)}
+	params := []struct {
+		name  string
+		def   int
+		field *int
+	}{
+		{"window", 30, &cfg.Window},
+		{"stride", 1, &cfg.Stride},
+		{"max_lag", 5, &cfg.MaxLag},
+	}
+	for _, p := range params {
+		*p.field, err = intQueryParam(c, p.name, p.def)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	records := map[string][]models.Record{}
+	for _, asset := range assets {
+		records[asset], err = fetchRecordsFromDB(h.DB, asset, start, end)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": err.Error(),
+			})
+		}
+		if len(records[asset]) == 0 {
+			return c.JSON(400, echo.Map{
+				"error": fmt.Sprintf("no records for %s in the date range", asset),
+			})
+		}
+	}
+
+	result, err := correlation.Analyse(records, cfg)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"correlations": result,
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+// Fields of models.Record that can be decomposed
+var decomposableFields = map[string]func(models.Record) float64{
+	"bid_ask_spread":    func(r models.Record) float64 { return r.BidAskSpread },
+	"spread_percentage": func(r models.Record) float64 { return r.BidAskSpread / r.BidPrice },
+	"volume":            func(r models.Record) float64 { return r.Volume },
+	"bid_price":         func(r models.Record) float64 { return r.BidPrice },
+}
+
+func (h *handler) handleGetDecomposition(c echo.Context) error {
+	asset, start, end, intervalLength, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	field := c.QueryParam("field")
+	if field == "" {
+		field = "volume"
+	}
+	selector, ok := decomposableFields[field]
+	if !ok {
+		return c.JSON(400, echo.Map{
+			"error": fmt.Sprintf("invalid 'field' %q, use bid_ask_spread, spread_percentage, volume or bid_price", field),
+		})
+	}
+	seasonLength, err := intQueryParam(c, "season_length", 0)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	robust := true
+	if value := c.QueryParam("robust"); value != "" {
+		robust, err = strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": "invalid 'robust', use true or false",
+			})
+		}
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	step := intervalStep(intervalLength)
+	records = stats.Resample(records, step)
+
+	values := make([]float64, 0, len(records))
+	timestamps := make([]time.Time, 0, len(records))
+	for _, record := range records {
+		if field == "spread_percentage" && record.BidPrice <= 0 {
+			continue // no spread percentage without a price
+		}
+		values = append(values, selector(record))
+		timestamps = append(timestamps, record.Timestamp)
+	}
+
+	// An explicit season length skips detection
+	var decomposition stats.STLDecomposition
+	if seasonLength > 0 {
+		decomposition, err = stats.STL(values, seasonLength, robust)
+	} else {
+		decomposition, err = stats.AutoSTL(values, stats.SeasonLengthsFor(step), robust)
+	}
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"asset":         asset,
+		"field":         field,
+		"timestamps":    timestamps,
+		"decomposition": decomposition,
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bedminer1/liquidity_tracker/internal/anomaly"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetDetectors(c echo.Context) error {
+	return c.JSON(200, echo.Map{
+		"detectors": anomaly.Available(),
+	})
+}
+
+// parseDetectors reads `detectors`, a comma-separated list of anomaly detectors or
+// "all". None run when it is absent.
+func parseDetectors(c echo.Context) ([]string, error) {
+	value := c.QueryParam("detectors")
+	if value == "" {
+		return nil, nil
+	}
+	if value == "all" {
+		var names []string
+		for _, info := range anomaly.Available() {
+			names = append(names, info.Name)
+		}
+		return names, nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, err := anomaly.New(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
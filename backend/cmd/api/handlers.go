@@ -1,17 +1,16 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"math/rand/v2"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bedminer1/liquidity_tracker/internal/blockchain"
 	"github.com/bedminer1/liquidity_tracker/internal/chatgpt"
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
 	"github.com/bedminer1/liquidity_tracker/internal/models"
 	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
 	"github.com/bedminer1/liquidity_tracker/internal/stats"
@@ -22,7 +21,8 @@ import (
 )
 
 type handler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Policies riskassessment.PolicySet
 }
 
 func initHandler() *handler {
@@ -30,9 +30,14 @@ func initHandler() *handler {
 	if err != nil {
 		panic("failed to connect database")
 	}
-	db.AutoMigrate()
+	db.AutoMigrate(&models.Portfolio{}, &models.Holding{}, &models.Scenario{}, &models.Shock{})
 
-	return &handler{DB: db}
+	policies, err := loadPolicies()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load risk policy: %v", err))
+	}
+
+	return &handler{DB: db, Policies: policies}
 }
 
 func (h *handler) handleGetRecords(c echo.Context) error {
@@ -109,16 +114,38 @@ func (h *handler) handleGetPredictions(c echo.Context) error {
 			"error": err,
 		})
 	}
-	predictions, err := getPredictionsFromAI(records, intervalLength, intervals)
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	// Raw records go to the forecaster: the native models resample them to the step
+	// themselves and the LSTM microservice expects the history as stored
+	model, predictions, modelFit, err := runForecast(c, records, "lstm", forecastOpts)
 	if err != nil {
 		return c.JSON(400, echo.Map{
-			"error": fmt.Sprintf("error interacting with microservice: %s", err.Error()),
+			"error": err.Error(),
 		})
 	}
 
+	// The Holt-Winters fit is reported whichever model forecasts, as a reference for the
+	// smoothing parameters and in-sample error of the series
+	holtWintersFit := modelFit
+	if model != "holt-winters" {
+		holtWintersFit = nil
+		if fitted, err := stats.FitRecords(records, forecastOpts.Step); err == nil {
+			holtWintersFit = fitted.Fit()
+		}
+	}
+
 	return c.JSON(200, echo.Map{
-		"historicalData": records,
-		"predictions":    predictions,
+		"historicalData":   records,
+		"predictions":      predictions,
+		"model":            model,
+		"model_fit":        modelFit,
+		"holt_winters_fit": holtWintersFit,
+		"forecast":         forecastSettings(forecastOpts),
 	})
 }
 
@@ -135,19 +162,72 @@ func (h *handler) handleGetReport(c echo.Context) error {
 			"error": err,
 		})
 	}
-	predictions, err := getPredictionsFromAI(records, intervalLength, intervals)
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	boundLevel, err := parseBoundLevel(c, forecastOpts.ConfidenceLevels)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	liquidityMetrics, err := parseMetrics(c, records, forecastOpts.Step)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	lvarConfig, err := parseLVaRConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	policy, err := h.policyFor(c, asset)
 	if err != nil {
 		return c.JSON(400, echo.Map{
-			"error": fmt.Sprintf("error interacting with microservice: %s", err.Error()),
+			"error": err.Error(),
+		})
+	}
+	detectors, err := parseDetectors(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	_, predictions, _, err := runForecast(c, records, "lstm", forecastOpts)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
 		})
 	}
-	liquidityReport := riskassessment.AssessLiquidity(records, predictions, 8)
+	liquidityReport := riskassessment.AssessLiquidity(records, predictions, riskassessment.Options{
+		Policy:       policy,
+		BoundLevel:   boundLevel,
+		Metrics:      liquidityMetrics,
+		LVaR:         &lvarConfig,
+		Detectors:    detectors,
+		Volatility:   true,
+		Regimes:      true,
+		ChangePoints: true,
+		Score:        true,
+	})
 
 	return c.JSON(200, echo.Map{
 		"report": liquidityReport,
 	})
 }
 
+func (h *handler) handleGetModels(c echo.Context) error {
+	return c.JSON(200, echo.Map{
+		"models": forecast.Available(),
+	})
+}
+
 // HELPER FUNCTIONS
 
 func parseQueryParams(c echo.Context) (string, time.Time, time.Time, int, int, error) {
@@ -168,54 +248,150 @@ func parseQueryParams(c echo.Context) (string, time.Time, time.Time, int, int, e
 	return asset, startTime, endTime, intervalLength, intervals, nil
 }
 
-func fetchRecordsFromDB(db *gorm.DB, asset string, start, end time.Time) ([]models.Record, error) {
-	records := []models.Record{}
-	err := db.Where("asset_type = ? AND timestamp BETWEEN ? AND ?", asset, start, end).Find(&records).Error
+// parseForecastOptions reads the `mode` (expected|stochastic), `seed` and `confidence` query params.
+// Stochastic runs without a seed get a random one, which is echoed back so the
+// report can be regenerated exactly.
+func parseForecastOptions(c echo.Context, intervalLength, intervals int) (forecast.Options, error) {
+	opts := forecast.Options{
+		Steps: intervals,
+		Step:  intervalStep(intervalLength),
+	}
+	mode := c.QueryParam("mode")
+	switch mode {
+	case "", "expected":
+	case "stochastic":
+		opts.Stochastic = true
+	default:
+		return opts, fmt.Errorf("invalid 'mode' %q, use expected or stochastic", mode)
+	}
+
+	// A seed alone implies a stochastic run, but can't override an explicit expected mode
+	if seed := c.QueryParam("seed"); seed != "" {
+		if mode == "expected" {
+			return opts, fmt.Errorf("'seed' only applies to mode=stochastic, the expected path has no noise")
+		}
+		parsed, err := strconv.ParseUint(seed, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid 'seed', use a non-negative integer")
+		}
+		opts.Seed = parsed
+		opts.Stochastic = true
+	} else if opts.Stochastic {
+		opts.Seed = rand.Uint64()
+	}
+
+	opts.ConfidenceLevels = []float64{0.8, 0.95}
+	if confidence := c.QueryParam("confidence"); confidence != "" {
+		opts.ConfidenceLevels = nil
+		for _, value := range strings.Split(confidence, ",") {
+			level, err := parseConfidenceLevel(value)
+			if err != nil {
+				return opts, fmt.Errorf("invalid 'confidence', %v", err)
+			}
+			opts.ConfidenceLevels = append(opts.ConfidenceLevels, level)
+		}
+	}
+	return opts, nil
+}
+
+// parseBoundLevel reads `bound_level`, the confidence level whose prediction bounds
+// AssessLiquidity should check. It must be one of the requested confidence levels.
+func parseBoundLevel(c echo.Context, confidenceLevels []float64) (float64, error) {
+	value := c.QueryParam("bound_level")
+	if value == "" {
+		return 0, nil
+	}
+	level, err := parseConfidenceLevel(value)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching records from database: %v", err)
+		return 0, fmt.Errorf("invalid 'bound_level', %v", err)
 	}
-	return records, nil
+	for _, l := range confidenceLevels {
+		if l == level {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("'bound_level' must be one of the requested 'confidence' levels")
 }
 
-func getPredictionsFromAI(currentRecords []models.Record, intervalLength, intervals int) ([]models.Record, error) {
-	// Convert records to JSON
-	jsonData, err := json.Marshal(currentRecords)
+// Helper function to accept confidence levels as percentages (95) or fractions (0.95)
+func parseConfidenceLevel(value string) (float64, error) {
+	level, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling current records: %v", err)
+		return 0, fmt.Errorf("use a percentage such as 95")
+	}
+	if level > 1 {
+		level /= 100
 	}
+	if level <= 0 || level >= 1 {
+		return 0, fmt.Errorf("levels must be between 0 and 100")
+	}
+	return level, nil
+}
 
-	// Send POST request to AI microservice
-	url := fmt.Sprintf("http://localhost:5433/predict?time_interval_length=%d&time_intervals=%d", intervalLength, intervals)
-	if intervalLength == 0 || intervals == 0 {
-		url = "http://localhost:5433/predict"
+// forecastSettings echoes the options used so a response can be reproduced
+func forecastSettings(opts forecast.Options) echo.Map {
+	settings := echo.Map{
+		"mode":                 "expected",
+		"time_interval_length": int(opts.Step / time.Second),
 	}
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error sending request to AI microservice: %v", err)
+	if opts.Stochastic {
+		settings["mode"] = "stochastic"
+		// seeds above 2^53 don't survive as JSON numbers in the browser
+		settings["seed"] = strconv.FormatUint(opts.Seed, 10)
 	}
-	defer resp.Body.Close()
+	return settings
+}
 
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(resp)
-		return nil, fmt.Errorf("AI microservice error: %s", string(body))
+// runForecast fits the model named by the `model` query param (defaultModel when absent)
+// and predicts opts.Steps records. The fit summary is nil for models that don't report one.
+func runForecast(c echo.Context, records []models.Record, defaultModel string, opts forecast.Options) (string, []models.Record, any, error) {
+	name := c.QueryParam("model")
+	if name == "" {
+		name = defaultModel
+	}
+	predictions, forecaster, err := forecast.Run(name, records, opts)
+	if err != nil {
+		return name, nil, nil, err
 	}
+	var modelFit any
+	if reporter, ok := forecaster.(forecast.FitReporter); ok {
+		modelFit = reporter.FitSummary()
+	}
+	return name, predictions, modelFit, nil
+}
 
-	// Parse response
-	var predictions []models.Record
-	err = json.NewDecoder(resp.Body).Decode(&predictions)
+// intQueryParam reads an optional integer query param, falling back to def when absent
+func intQueryParam(c echo.Context, name string, def int) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return def, nil
+	}
+	parsed, err := strconv.Atoi(value)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding response from AI microservice: %v", err)
+		return 0, fmt.Errorf("invalid '%s', use an integer", name)
 	}
+	return parsed, nil
+}
 
-	return predictions, nil
+// intervalStep converts time_interval_length (seconds) into a forecast step, daily by default
+func intervalStep(intervalLength int) time.Duration {
+	if intervalLength <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(intervalLength) * time.Second
+}
+
+func fetchRecordsFromDB(db *gorm.DB, asset string, start, end time.Time) ([]models.Record, error) {
+	records := []models.Record{}
+	err := db.Where("asset_type = ? AND timestamp BETWEEN ? AND ?", asset, start, end).Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching records from database: %v", err)
+	}
+	return records, nil
 }
 
 func (h *handler) handleGetChatGPTRecommendation(c echo.Context) error {
 	asset, start, end, intervalLength, intervals, err := parseQueryParams(c)
-	intervals *= (intervalLength / 86400)
-
 	if err != nil {
 		return c.JSON(400, echo.Map{
 			"error": err,
@@ -228,17 +404,65 @@ func (h *handler) handleGetChatGPTRecommendation(c echo.Context) error {
 		})
 	}
 
-	// predictions, err := getPredictionsFromAI(records, intervalLength, intervals)
-	// if err != nil {
-	// 	return c.JSON(400, echo.Map{
-	// 		"error": fmt.Sprintf("error interacting with microservice: %s", err.Error()),
-	// 	})
-	// }
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
 
-	// PREDICTIONS USING HOLT-WINTERS MODEL
-	predictions := stats.GeneratePredictions(records, intervals)
+	liquidityMetrics, err := parseMetrics(c, records, forecastOpts.Step)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	lvarConfig, err := parseLVaRConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	policy, err := h.policyFor(c, asset)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	detectors, err := parseDetectors(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Assess history at the same granularity as the forecast
+	records = stats.Resample(records, forecastOpts.Step)
+	boundLevel, err := parseBoundLevel(c, forecastOpts.ConfidenceLevels)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
 
-	liquidityReport := riskassessment.AssessLiquidity(records, predictions, 8)
+	model, predictions, modelFit, err := runForecast(c, records, "holt-winters", forecastOpts)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	liquidityReport := riskassessment.AssessLiquidity(records, predictions, riskassessment.Options{
+		Policy:       policy,
+		BoundLevel:   boundLevel,
+		Metrics:      liquidityMetrics,
+		LVaR:         &lvarConfig,
+		Detectors:    detectors,
+		Volatility:   true,
+		Regimes:      true,
+		ChangePoints: true,
+		Score:        true,
+	})
 	response, err := chatgpt.FetchGPTResponse(liquidityReport)
 	if err != nil {
 		return c.JSON(400, echo.Map{
@@ -251,5 +475,8 @@ func (h *handler) handleGetChatGPTRecommendation(c echo.Context) error {
 		"report":          liquidityReport,
 		"historical_data": records,
 		"predictions":     predictions,
+		"model":           model,
+		"model_fit":       modelFit,
+		"forecast":        forecastSettings(forecastOpts),
 	})
 }
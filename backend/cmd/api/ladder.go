@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetLadder(c echo.Context) error {
+	_, start, end, _, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	cfg, err := parseLadderConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "csv" {
+		return c.JSON(400, echo.Map{
+			"error": fmt.Sprintf("invalid 'format' %q, use json or csv", format),
+		})
+	}
+	stored, err := h.loadPortfolio(c.QueryParam("portfolio"))
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var holdings []riskassessment.LadderHolding
+	for _, holding := range stored.Holdings {
+		records, err := fetchRecordsFromDB(h.DB, holding.AssetType, start, end)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": err.Error(),
+			})
+		}
+		holdings = append(holdings, riskassessment.LadderHolding{
+			AssetType: holding.AssetType,
+			Quantity:  holding.Quantity,
+			Records:   records,
+		})
+	}
+	ladder := riskassessment.BuildLadder(holdings, cfg)
+
+	if format == "csv" {
+		data, err := ladderCSV(ladder)
+		if err != nil {
+			return c.JSON(500, echo.Map{
+				"error": err.Error(),
+			})
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", stored.Name+"_ladder.csv"))
+		return c.Blob(200, "text/csv", data)
+	}
+	return c.JSON(200, echo.Map{
+		"portfolio": stored.Name,
+		"ladder":    ladder,
+	})
+}
+
+// parseLadderConfig reads `max_participation` (percentage of daily volume, default 10)
+// and `outflows` (30-day net outflows, for the LCR)
+func parseLadderConfig(c echo.Context) (riskassessment.LadderConfig, error) {
+	var cfg riskassessment.LadderConfig
+	if value := c.QueryParam("max_participation"); value != "" {
+		participation, err := strconv.ParseFloat(value, 64)
+		if err != nil || participation <= 0 || participation > 100 {
+			return cfg, fmt.Errorf("invalid 'max_participation', use a percentage between 0 and 100")
+		}
+		cfg.MaxParticipation = participation / 100
+	}
+	if value := c.QueryParam("outflows"); value != "" {
+		outflows, err := strconv.ParseFloat(value, 64)
+		if err != nil || outflows <= 0 {
+			return cfg, fmt.Errorf("invalid 'outflows', use a positive amount")
+		}
+		cfg.Outflows = outflows
+	}
+	return cfg, nil
+}
+
+// ladderCSV writes one row per holding and a total row. The capped HQLA and LCR are
+// only in the JSON form since they don't split by holding.
+func ladderCSV(ladder models.LiquidityLadder) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"asset_type", "quantity", "price", "value", "days_to_liquidate", "relative_spread", "hqla_level", "haircut"}
+	header = append(header, ladder.Buckets...)
+	header = append(header, "hqla_value", "error")
+	w.Write(header)
+
+	format := func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) }
+	hqlaValue := 0.0
+	for _, row := range ladder.Rows {
+		line := []string{row.AssetType, format(row.Quantity), format(row.Price), format(row.Value),
+			format(row.DaysToLiquidate), format(row.RelativeSpread), row.HQLALevel, format(row.Haircut)}
+		for _, value := range row.Buckets {
+			line = append(line, format(value))
+		}
+		line = append(line, format(row.HQLAValue), row.Error)
+		w.Write(line)
+		hqlaValue += row.HQLAValue
+	}
+
+	total := []string{"TOTAL", "", "", format(ladder.TotalValue), "", "", "", ""}
+	for _, value := range ladder.Totals {
+		total = append(total, format(value))
+	}
+	total = append(total, format(hqlaValue), "")
+	w.Write(total)
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bedminer1/liquidity_tracker/internal/liquidation"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetLiquidationPlan(c echo.Context) error {
+	asset, start, end, intervalLength, intervals, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if intervals <= 0 {
+		intervals = 30
+	}
+	cfg, err := parseLiquidationConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	cfg.Step = forecastOpts.Step
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	records = stats.Resample(records, forecastOpts.Step)
+
+	model, predictions, _, err := runForecast(c, records, "holt-winters", forecastOpts)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	plan, err := liquidation.Build(records, predictions, cfg)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"asset":    asset,
+		"model":    model,
+		"forecast": forecastSettings(forecastOpts),
+		"plan":     plan,
+	})
+}
+
+// parseLiquidationConfig reads `quantity` (units to sell, required), `max_participation`
+// (percentage of each period's volume, default 10) and `impact` (square-root impact coefficient)
+func parseLiquidationConfig(c echo.Context) (liquidation.Config, error) {
+	quantity, err := strconv.ParseFloat(c.QueryParam("quantity"), 64)
+	if err != nil || quantity <= 0 {
+		return liquidation.Config{}, fmt.Errorf("invalid 'quantity', use a positive number of units")
+	}
+	cfg, err := parseExecutionLimits(c)
+	cfg.Position = quantity
+	return cfg, err
+}
+
+// parseExecutionLimits reads the `max_participation` and `impact` params shared by
+// every endpoint that plans a liquidation
+func parseExecutionLimits(c echo.Context) (liquidation.Config, error) {
+	var cfg liquidation.Config
+	cfg.MaxParticipation = 0.1
+	if value := c.QueryParam("max_participation"); value != "" {
+		participation, err := strconv.ParseFloat(value, 64)
+		if err != nil || participation <= 0 || participation > 100 {
+			return cfg, fmt.Errorf("invalid 'max_participation', use a percentage between 0 and 100")
+		}
+		cfg.MaxParticipation = participation / 100
+	}
+
+	if value := c.QueryParam("impact"); value != "" {
+		impact, err := strconv.ParseFloat(value, 64)
+		if err != nil || impact <= 0 {
+			return cfg, fmt.Errorf("invalid 'impact', use a positive number")
+		}
+		cfg.ImpactCoefficient = impact
+	}
+	return cfg, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetLVaR(c echo.Context) error {
+	asset, start, end, intervalLength, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	cfg, err := parseLVaRConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	records = stats.Resample(records, intervalStep(intervalLength))
+
+	lvar, err := riskassessment.ComputeLVaR(records, cfg)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"asset": asset,
+		"lvar":  lvar,
+	})
+}
+
+// parseLVaRConfig reads `lvar_confidence` (default 99), `horizon` in time intervals
+// (default 1) and `position`, the value of the holding in the price currency
+func parseLVaRConfig(c echo.Context) (riskassessment.LVaRConfig, error) {
+	var cfg riskassessment.LVaRConfig
+	if value := c.QueryParam("lvar_confidence"); value != "" {
+		level, err := parseConfidenceLevel(value)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid 'lvar_confidence', %v", err)
+		}
+		cfg.Confidence = level
+	}
+	horizon, err := intQueryParam(c, "horizon", riskassessment.DefaultLVaRHorizon)
+	if err != nil {
+		return cfg, err
+	}
+	if horizon < 1 {
+		return cfg, fmt.Errorf("'horizon' must be at least 1")
+	}
+	cfg.Horizon = horizon
+	if value := c.QueryParam("position"); value != "" {
+		cfg.PositionValue, err = strconv.ParseFloat(value, 64)
+		if err != nil || cfg.PositionValue < 0 {
+			return cfg, fmt.Errorf("invalid 'position', use a non-negative number")
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/metrics"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetMetrics(c echo.Context) error {
+	asset, start, end, intervalLength, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	window, err := intQueryParam(c, "window", 20)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	step := intervalStep(intervalLength)
+
+	// Metrics work on the raw records so each period keeps its own high and low
+	overall, err := metrics.Overall(records, step)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	series, err := metrics.Compute(records, metrics.Config{Step: step, Window: window})
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"asset":   asset,
+		"window":  window,
+		"overall": overall,
+		"rolling": series,
+	})
+}
+
+// Helper function to compute rolling liquidity metrics for the assessment when the
+// request sets metrics=true, from raw records so they must be passed in before resampling
+func parseMetrics(c echo.Context, records []models.Record, step time.Duration) ([]models.LiquidityMetrics, error) {
+	if value := c.QueryParam("metrics"); value != "" {
+		include, err := strconv.ParseBool(value)
+		if err != nil || !include {
+			return nil, err
+		}
+		window, err := intQueryParam(c, "metrics_window", 20)
+		if err != nil {
+			return nil, err
+		}
+		return metrics.Compute(records, metrics.Config{Step: step, Window: window})
+	}
+	return nil, nil
+}
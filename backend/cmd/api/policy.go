@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/labstack/echo/v4"
+)
+
+// Where the risk policy file is read from unless RISK_POLICY_FILE is set
+const defaultPolicyFile = "../../risk_policy.yaml"
+
+// loadPolicies reads the risk policy file. Without one every asset gets the default policy.
+func loadPolicies() (riskassessment.PolicySet, error) {
+	path := os.Getenv("RISK_POLICY_FILE")
+	if path == "" {
+		path = defaultPolicyFile
+	}
+	policies, err := riskassessment.LoadPolicies(path)
+	if errors.Is(err, fs.ErrNotExist) && os.Getenv("RISK_POLICY_FILE") == "" {
+		return riskassessment.PolicySet{}, nil
+	}
+	return policies, err
+}
+
+// policyFor selects the risk policy for the asset and applies any thresholds given as
+// query params, e.g. high_spread_multiple=4 or window_size=24. A param set to 0 overrides
+// the threshold to 0 rather than being ignored.
+func (h *handler) policyFor(c echo.Context, asset string) (models.RiskPolicy, error) {
+	policy := h.Policies.For(asset)
+
+	var override models.PolicyOverride
+	overridden := false
+	if c.QueryParam("window_size") != "" {
+		windowSize, err := intQueryParam(c, "window_size", 0)
+		if err != nil {
+			return policy, err
+		}
+		override.WindowSize = &windowSize
+		overridden = true
+	}
+	thresholds := []struct {
+		name  string
+		field **float64
+	}{
+		{"high_spread_multiple", &override.HighSpreadMultiple},
+		{"high_volume_multiple", &override.HighVolumeMultiple},
+		{"moderate_spread_multiple", &override.ModerateSpreadMultiple},
+		{"moderate_volume_multiple", &override.ModerateVolumeMultiple},
+		{"min_spread", &override.MinSpread},
+	}
+	for _, threshold := range thresholds {
+		value := c.QueryParam(threshold.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return policy, fmt.Errorf("invalid '%s', use a number", threshold.name)
+		}
+		*threshold.field = &parsed
+		overridden = true
+	}
+
+	if overridden {
+		name := policy.Name + " (overridden)"
+		override.Name = &name
+		policy = riskassessment.MergePolicy(policy, override)
+	}
+	if err := riskassessment.ValidatePolicy(policy); err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
+	"github.com/bedminer1/liquidity_tracker/internal/liquidation"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/portfolio"
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func (h *handler) handleGetPortfolios(c echo.Context) error {
+	var portfolios []models.Portfolio
+	if err := h.DB.Preload("Holdings").Order("name").Find(&portfolios).Error; err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(200, echo.Map{
+		"portfolios": portfolios,
+	})
+}
+
+// handleSavePortfolio creates the portfolio in the request body, replacing the
+// holdings of any existing portfolio with the same name
+func (h *handler) handleSavePortfolio(c echo.Context) error {
+	var body models.Portfolio
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := validatePortfolio(body); err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.Portfolio
+		if err := tx.Where("name = ?", body.Name).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if existing.ID == 0 {
+			return tx.Create(&body).Error
+		}
+		if err := tx.Where("portfolio_id = ?", existing.ID).Delete(&models.Holding{}).Error; err != nil {
+			return err
+		}
+		for i := range body.Holdings {
+			body.Holdings[i].PortfolioID = existing.ID
+		}
+		body.ID = existing.ID
+		return tx.Create(&body.Holdings).Error
+	})
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(200, echo.Map{
+		"portfolio": body,
+	})
+}
+
+func validatePortfolio(p models.Portfolio) error {
+	if p.Name == "" {
+		return fmt.Errorf("portfolio needs a 'name'")
+	}
+	if len(p.Holdings) == 0 {
+		return fmt.Errorf("portfolio needs at least one holding")
+	}
+	seen := make(map[string]bool)
+	for _, holding := range p.Holdings {
+		if holding.AssetType == "" {
+			return fmt.Errorf("every holding needs an 'asset_type'")
+		}
+		if holding.Quantity <= 0 {
+			return fmt.Errorf("holding %s needs a positive 'quantity'", holding.AssetType)
+		}
+		if seen[holding.AssetType] {
+			return fmt.Errorf("%s is held more than once, combine the quantities", holding.AssetType)
+		}
+		seen[holding.AssetType] = true
+	}
+	return nil
+}
+
+func (h *handler) handleGetPortfolioReport(c echo.Context) error {
+	_, start, end, intervalLength, intervals, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if intervals <= 0 {
+		intervals = 30
+	}
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	limits, err := parseExecutionLimits(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	stored, err := h.loadPortfolio(c.QueryParam("portfolio"))
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	inputs, err := h.assessHoldings(c, stored, start, end, forecastOpts, limits)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"forecast": forecastSettings(forecastOpts),
+		"report":   portfolio.Aggregate(stored.Name, inputs, forecastOpts.Step),
+	})
+}
+
+func (h *handler) loadPortfolio(name string) (models.Portfolio, error) {
+	var stored models.Portfolio
+	if name == "" {
+		return stored, fmt.Errorf("missing 'portfolio', use the name of a saved portfolio")
+	}
+	if err := h.DB.Preload("Holdings").Where("name = ?", name).Limit(1).Find(&stored).Error; err != nil {
+		return stored, err
+	}
+	if stored.ID == 0 {
+		return stored, fmt.Errorf("unknown portfolio %q", name)
+	}
+	return stored, nil
+}
+
+// assessHoldings runs the risk report and liquidation plan for every holding. A holding
+// that can't be assessed is kept with its error so its value still counts as illiquid.
+// Only invalid policy overrides and a cancelled request are returned as errors.
+func (h *handler) assessHoldings(c echo.Context, p models.Portfolio, start, end time.Time, forecastOpts forecast.Options, limits liquidation.Config) ([]portfolio.Input, error) {
+	var inputs []portfolio.Input
+	for _, holding := range p.Holdings {
+		if err := c.Request().Context().Err(); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, portfolio.Input{Holding: holding})
+		current := &inputs[len(inputs)-1]
+
+		policy, err := h.policyFor(c, holding.AssetType)
+		if err != nil {
+			return nil, err
+		}
+		records, err := fetchRecordsFromDB(h.DB, holding.AssetType, start, end)
+		if err != nil {
+			current.Error = err.Error()
+			continue
+		}
+		records = stats.Resample(records, forecastOpts.Step)
+		if len(records) == 0 {
+			current.Error = "no records in the requested range"
+			continue
+		}
+		current.Price = records[len(records)-1].BidPrice
+
+		_, predictions, _, err := runForecast(c, records, "holt-winters", forecastOpts)
+		if err != nil {
+			current.Error = err.Error()
+			continue
+		}
+		current.Report = riskassessment.AssessLiquidity(records, predictions, riskassessment.Options{Policy: policy, Score: true})
+
+		cfg := limits
+		cfg.Position = holding.Quantity
+		cfg.Step = forecastOpts.Step
+		current.Plan, err = liquidation.Build(records, predictions, cfg)
+		if err != nil {
+			current.Error = err.Error()
+		}
+	}
+	return inputs, nil
+}
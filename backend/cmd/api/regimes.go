@@ -0,0 +1,29 @@
+package main
+
+import (
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) handleGetRegimes(c echo.Context) error {
+	asset, start, end, intervalLength, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	records = stats.Resample(records, intervalStep(intervalLength))
+
+	return c.JSON(200, echo.Map{
+		"asset":   asset,
+		"regimes": riskassessment.AssessRegimes(records, true),
+	})
+}
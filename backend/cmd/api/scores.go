@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+// assetScore is one row of the /scores ranking
+type assetScore struct {
+	Rank      int              `json:"rank"`
+	AssetType string           `json:"asset_type"`
+	Records   int              `json:"records"`
+	Score     models.RiskScore `json:"score"`
+	Error     string           `json:"error,omitempty"` // set when the forecast failed and only history was scored
+}
+
+func (h *handler) handleGetScores(c echo.Context) error {
+	_, start, end, intervalLength, intervals, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if intervals <= 0 {
+		intervals = 7
+	}
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var assets []string
+	if err := h.DB.Model(&models.Record{}).Distinct("asset_type").Pluck("asset_type", &assets).Error; err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var scores []assetScore
+	for _, asset := range assets {
+		if err := c.Request().Context().Err(); err != nil {
+			return err
+		}
+		policy, err := h.policyFor(c, asset)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": err.Error(),
+			})
+		}
+		records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": err.Error(),
+			})
+		}
+		records = stats.Resample(records, forecastOpts.Step)
+		if len(records) == 0 {
+			continue
+		}
+
+		row := assetScore{AssetType: asset, Records: len(records)}
+		_, predictions, _, err := runForecast(c, records, "holt-winters", forecastOpts)
+		if err != nil {
+			row.Error = err.Error()
+		}
+		// Only the score is needed, so skip the model fits a full assessment runs
+		high, moderate := riskassessment.PredictedRisk(records, predictions, policy)
+		row.Score = riskassessment.ScoreLiquidity(records, countFlags(high), countFlags(moderate), len(predictions), policy.WindowSize)
+		scores = append(scores, row)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score.Score > scores[j].Score.Score })
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+
+	return c.JSON(200, echo.Map{
+		"forecast": forecastSettings(forecastOpts),
+		"scores":   scores,
+	})
+}
+
+func countFlags(flags []bool) int {
+	count := 0
+	for _, flag := range flags {
+		if flag {
+			count++
+		}
+	}
+	return count
+}
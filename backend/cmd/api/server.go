@@ -16,6 +16,25 @@ func main() {
 	e.GET("/predictions", h.handleGetPredictions)
 	e.GET("/report", h.handleGetReport)
 	e.GET("/recommendations", h.handleGetChatGPTRecommendation)
+	e.GET("/backtest", h.handleGetBacktest)
+	e.GET("/models", h.handleGetModels)
+	e.GET("/detectors", h.handleGetDetectors)
+	e.GET("/simulate", h.handleGetSimulation)
+	e.GET("/regimes", h.handleGetRegimes)
+	e.GET("/changepoints", h.handleGetChangePoints)
+	e.GET("/decompose", h.handleGetDecomposition)
+	e.GET("/metrics", h.handleGetMetrics)
+	e.GET("/lvar", h.handleGetLVaR)
+	e.GET("/liquidation_plan", h.handleGetLiquidationPlan)
+	e.GET("/scores", h.handleGetScores)
+	e.GET("/correlations", h.handleGetCorrelations)
+	e.GET("/portfolios", h.handleGetPortfolios)
+	e.POST("/portfolios", h.handleSavePortfolio)
+	e.GET("/portfolio/report", h.handleGetPortfolioReport)
+	e.GET("/portfolio/ladder", h.handleGetLadder)
+	e.GET("/scenarios", h.handleGetScenarios)
+	e.POST("/scenarios", h.handleSaveScenario)
+	e.GET("/stress", h.handleGetStress)
 
 	e.Logger.Fatal(e.Start(":4000"))
 }
\ No newline at end of file
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
+	"github.com/bedminer1/liquidity_tracker/internal/simulation"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/labstack/echo/v4"
+)
+
+// Upper bound on paths per request, keeps a single call from tying up the server
+const maxSimulationPaths = 100000
+
+func (h *handler) handleGetSimulation(c echo.Context) error {
+	asset, start, end, intervalLength, intervals, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if intervals <= 0 {
+		intervals = 30
+	}
+	paths, err := intQueryParam(c, "paths", 1000)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if paths < 1 || paths > maxSimulationPaths {
+		return c.JSON(400, echo.Map{
+			"error": fmt.Sprintf("'paths' must be between 1 and %d", maxSimulationPaths),
+		})
+	}
+	seed := rand.Uint64()
+	if value := c.QueryParam("seed"); value != "" {
+		seed, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": "invalid 'seed', use a non-negative integer",
+			})
+		}
+	}
+
+	modelName := c.QueryParam("model")
+	if modelName == "" {
+		modelName = "holt-winters"
+	}
+	if info, ok := forecast.Lookup(modelName); !ok || !info.Stochastic {
+		return c.JSON(400, echo.Map{
+			"error": fmt.Sprintf("model %q can't simulate stochastic paths", modelName),
+		})
+	}
+
+	policy, err := h.policyFor(c, asset)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	step := intervalStep(intervalLength)
+	records = stats.Resample(records, step)
+
+	forecaster, err := forecast.New(modelName)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := forecaster.Fit(records, step); err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := simulation.Run(c.Request().Context(), forecaster, records, simulation.Config{
+		Paths:   paths,
+		Steps:   intervals,
+		Step:    step,
+		Seed:    seed,
+		Workers: runtime.NumCPU(),
+		Policy:  policy,
+	})
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(200, echo.Map{
+		"asset":      asset,
+		"model":      modelName,
+		"simulation": result,
+	})
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+	"github.com/bedminer1/liquidity_tracker/internal/stress"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func (h *handler) handleGetScenarios(c echo.Context) error {
+	var scenarios []models.Scenario
+	if err := h.DB.Preload("Shocks").Order("name").Find(&scenarios).Error; err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(200, echo.Map{
+		"scenarios": scenarios,
+	})
+}
+
+// handleSaveScenario creates the scenario in the request body, replacing the shocks of
+// any existing scenario with the same name
+func (h *handler) handleSaveScenario(c echo.Context) error {
+	var body models.Scenario
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := stress.Validate(body); err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.Scenario
+		if err := tx.Where("name = ?", body.Name).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if existing.ID == 0 {
+			return tx.Create(&body).Error
+		}
+		if err := tx.Where("scenario_id = ?", existing.ID).Delete(&models.Shock{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Update("description", body.Description).Error; err != nil {
+			return err
+		}
+		for i := range body.Shocks {
+			body.Shocks[i].ScenarioID = existing.ID
+		}
+		body.ID = existing.ID
+		return tx.Create(&body.Shocks).Error
+	})
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(200, echo.Map{
+		"scenario": body,
+	})
+}
+
+func (h *handler) handleGetStress(c echo.Context) error {
+	if c.QueryParam("replay") != "" {
+		return h.handleGetReplay(c)
+	}
+	asset, start, end, intervalLength, intervals, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if intervals <= 0 {
+		intervals = 14
+	}
+	forecastOpts, err := parseForecastOptions(c, intervalLength, intervals)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	lvarConfig, err := parseLVaRConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	policy, err := h.policyFor(c, asset)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	scenario, err := h.loadScenario(c.QueryParam("scenario"), asset)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	records = stats.Resample(records, forecastOpts.Step)
+	if len(records) == 0 {
+		return c.JSON(400, echo.Map{
+			"error": "no records in the requested range",
+		})
+	}
+
+	model, predictions, _, err := runForecast(c, records, "holt-winters", forecastOpts)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Shocks start with the forecast unless `from` moves them back into history
+	shockStart := records[len(records)-1].Timestamp.Add(forecastOpts.Step)
+	if from := c.QueryParam("from"); from != "" {
+		shockStart, err = time.Parse("2006-01-02", from)
+		if err != nil {
+			return c.JSON(400, echo.Map{
+				"error": "invalid 'from' date format, use YYYY-MM-DD",
+			})
+		}
+	}
+
+	shockedRecords, err := stress.Apply(records, scenario, shockStart)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	shockedPredictions, err := stress.Apply(predictions, scenario, shockStart)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	opts := riskassessment.Options{Policy: policy, LVaR: &lvarConfig, Score: true, MeasurePredictions: true}
+	baseline := riskassessment.AssessLiquidity(records, predictions, opts)
+	stressed := riskassessment.AssessLiquidity(shockedRecords, shockedPredictions, opts)
+
+	return c.JSON(200, echo.Map{
+		"asset":       asset,
+		"model":       model,
+		"forecast":    forecastSettings(forecastOpts),
+		"scenario":    scenario,
+		"shock_start": shockStart,
+		"comparison":  stress.Compare(baseline, stressed),
+		"baseline":    baseline,
+		"stressed":    stressed,
+	})
+}
+
+// handleGetReplay replays an episode from the `replay` asset between `replay_start` and
+// `replay_end` onto the asset, in place of its forecast. The baseline forecasts as many
+// steps as the episode has so both reports cover the same horizon.
+func (h *handler) handleGetReplay(c echo.Context) error {
+	asset, start, end, intervalLength, _, err := parseQueryParams(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	if c.QueryParam("scenario") != "" {
+		return c.JSON(400, echo.Map{
+			"error": "use either 'scenario' or 'replay', not both",
+		})
+	}
+	source := c.QueryParam("replay")
+	episodeStart, err := time.Parse("2006-01-02", c.QueryParam("replay_start"))
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": "invalid 'replay_start' date format, use YYYY-MM-DD",
+		})
+	}
+	episodeEnd, err := time.Parse("2006-01-02", c.QueryParam("replay_end"))
+	if err != nil || !episodeEnd.After(episodeStart) {
+		return c.JSON(400, echo.Map{
+			"error": "invalid 'replay_end', use a YYYY-MM-DD date after 'replay_start'",
+		})
+	}
+	lookback, err := intQueryParam(c, "lookback", stress.DefaultReplayLookback)
+	if err != nil || lookback <= 0 {
+		return c.JSON(400, echo.Map{
+			"error": "invalid 'lookback', use a positive number of time intervals",
+		})
+	}
+	lvarConfig, err := parseLVaRConfig(c)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	policy, err := h.policyFor(c, asset)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	step := intervalStep(intervalLength)
+	sourceRecords, err := fetchRecordsFromDB(h.DB, source, episodeStart.Add(-time.Duration(lookback)*step), episodeEnd)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	path, err := stress.ReplayPath(stats.Resample(sourceRecords, step), episodeStart, lookback)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	records, err := fetchRecordsFromDB(h.DB, asset, start, end)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	records = stats.Resample(records, step)
+	replayed, err := stress.Replay(records, path, step, lookback)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	forecastOpts, err := parseForecastOptions(c, intervalLength, len(path))
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+	model, predictions, _, err := runForecast(c, records, "holt-winters", forecastOpts)
+	if err != nil {
+		return c.JSON(400, echo.Map{
+			"error": err.Error(),
+		})
+	}
+
+	opts := riskassessment.Options{Policy: policy, LVaR: &lvarConfig, Score: true, MeasurePredictions: true}
+	baseline := riskassessment.AssessLiquidity(records, predictions, opts)
+	replay := riskassessment.AssessLiquidity(records, replayed, opts)
+
+	return c.JSON(200, echo.Map{
+		"asset":    asset,
+		"model":    model,
+		"forecast": forecastSettings(forecastOpts),
+		"replay": echo.Map{
+			"source": source,
+			"start":  episodeStart,
+			"end":    episodeEnd,
+			"path":   path,
+		},
+		"comparison": stress.Compare(baseline, replay),
+		"baseline":   baseline,
+		"replayed":   replay,
+	})
+}
+
+// loadScenario fetches a saved scenario and checks at least one of its shocks covers the asset
+func (h *handler) loadScenario(name, asset string) (models.Scenario, error) {
+	var scenario models.Scenario
+	if name == "" {
+		return scenario, fmt.Errorf("missing 'scenario', use the name of a saved scenario")
+	}
+	if err := h.DB.Preload("Shocks").Where("name = ?", name).Limit(1).Find(&scenario).Error; err != nil {
+		return scenario, err
+	}
+	if scenario.ID == 0 {
+		return scenario, fmt.Errorf("unknown scenario %q", name)
+	}
+	for _, shock := range scenario.Shocks {
+		if stress.Applies(shock, asset) {
+			return scenario, nil
+		}
+	}
+	return scenario, fmt.Errorf("scenario %q has no shocks for %s", name, asset)
+}
@@ -0,0 +1,143 @@
+package anomaly
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/registry"
+)
+
+// Features each detector looks at, one column per record
+const (
+	FeatureSpread   = "spread_percentage"
+	FeatureVolume   = "log_volume"
+	FeatureReturn   = "bid_price_return"
+	featureCount    = 3
+	defaultWindow   = 20 // trailing records the rolling detectors compare against
+	minDetectRecord = 10 // fewer records than this are never flagged
+)
+
+var featureNames = [featureCount]string{FeatureSpread, FeatureVolume, FeatureReturn}
+
+// Flag marks one anomalous record
+type Flag struct {
+	Index    int      // position in the records passed to Detect
+	Score    float64  // detector specific, larger is more anomalous
+	Features []string // features that looked anomalous, empty when the detector can't tell
+}
+
+// Detector finds anomalous records in a time-ordered series
+type Detector interface {
+	Detect(records []models.Record) []Flag
+}
+
+type Factory func() Detector
+
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var detectors = registry.New[Info, Detector]("detector")
+
+// Register adds a detector to the ones Run and /report's `detectors` accept
+func Register(info Info, factory Factory) {
+	detectors.Register(info.Name, info, factory)
+}
+
+// New returns a detector by name
+func New(name string) (Detector, error) {
+	return detectors.New(name)
+}
+
+// Available describes each detector, sorted by name
+func Available() []Info {
+	return detectors.Available()
+}
+
+// Run applies each named detector to records and merges their flags into one anomaly
+// per record, keeping which detectors flagged it and why. Anomalies are in record order.
+func Run(records []models.Record, names []string) (models.AnomalyReport, error) {
+	report := models.AnomalyReport{Detectors: names, Counts: map[string]int{}}
+	byIndex := map[int]*models.Anomaly{}
+	for _, name := range names {
+		detector, err := New(name)
+		if err != nil {
+			return models.AnomalyReport{}, err
+		}
+		for _, flag := range detector.Detect(records) {
+			anomaly, ok := byIndex[flag.Index]
+			if !ok {
+				anomaly = &models.Anomaly{
+					Timestamp: records[flag.Index].Timestamp,
+					AssetType: records[flag.Index].AssetType,
+				}
+				byIndex[flag.Index] = anomaly
+			}
+			anomaly.Detections = append(anomaly.Detections, models.Detection{
+				Detector: name,
+				Score:    flag.Score,
+				Features: flag.Features,
+			})
+			report.Counts[name]++
+		}
+	}
+
+	indices := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	for _, index := range indices {
+		anomaly := *byIndex[index]
+		anomaly.Index = index
+		report.Anomalies = append(report.Anomalies, anomaly)
+	}
+	return report, nil
+}
+
+// Helper function to extract spread percentage, log volume and bid price log return for
+// each record. The first record's return is 0.
+func features(records []models.Record) [][featureCount]float64 {
+	out := make([][featureCount]float64, len(records))
+	for i, record := range records {
+		if record.BidPrice > 0 {
+			out[i][0] = record.BidAskSpread / record.BidPrice
+		}
+		out[i][1] = math.Log1p(math.Max(record.Volume, 0))
+		if i > 0 && record.BidPrice > 0 && records[i-1].BidPrice > 0 {
+			out[i][2] = math.Log(record.BidPrice / records[i-1].BidPrice)
+		}
+	}
+	return out
+}
+
+// Helper function for the trailing window detectors: score(f, history, x) returns how
+// far x sits from the history of feature f, and a record is flagged when any feature's
+// score reaches threshold. Returns aren't judged on the first record.
+func rollingDetect(records []models.Record, window int, threshold float64, score func(history []float64, x float64) float64) []Flag {
+	columns := features(records)
+	var flags []Flag
+	for t := max(window, minDetectRecord); t < len(records); t++ {
+		flag := Flag{Index: t}
+		for f := 0; f < featureCount; f++ {
+			history := make([]float64, 0, window)
+			for i := t - window; i < t; i++ {
+				if f == 2 && i == 0 {
+					continue
+				}
+				history = append(history, columns[i][f])
+			}
+			s := math.Abs(score(history, columns[t][f]))
+			if s >= threshold {
+				flag.Features = append(flag.Features, featureNames[f])
+				flag.Score = math.Max(flag.Score, s)
+			}
+		}
+		if len(flag.Features) > 0 {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
@@ -0,0 +1,87 @@
+package anomaly
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+const outlier = 150
+
+// Helper function for 200 calm daily records with a tenfold spread blowout at record 150
+func recordsWithOutlier() []models.Record {
+	rng := rand.New(rand.NewPCG(1, 1))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]models.Record, 200)
+	price := 100.0
+	for i := range records {
+		price *= math.Exp(0.005 * rng.NormFloat64())
+		records[i] = models.Record{
+			AssetType:    "TEST",
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			BidAskSpread: price * (0.002 + 0.0001*rng.NormFloat64()),
+			Volume:       1000 * math.Exp(0.05*rng.NormFloat64()),
+			BidPrice:     price,
+		}
+	}
+	records[outlier].BidAskSpread *= 10
+	return records
+}
+
+func TestDetectorsFlagInjectedOutlier(t *testing.T) {
+	records := recordsWithOutlier()
+	for _, info := range Available() {
+		detector, err := New(info.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flags := detector.Detect(records)
+		found := false
+		for _, flag := range flags {
+			if flag.Index == outlier {
+				found = true
+				if len(flag.Features) > 0 && flag.Features[0] != FeatureSpread {
+					t.Errorf("%s blamed %v for a spread blowout", info.Name, flag.Features)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s missed the spread blowout", info.Name)
+		}
+		// A handful of false alarms on 200 calm records is fine, flagging most isn't
+		if len(flags) > 20 {
+			t.Errorf("%s flagged %d of 200 records", info.Name, len(flags))
+		}
+	}
+}
+
+func TestRunMergesDetectors(t *testing.T) {
+	records := recordsWithOutlier()
+	report, err := Run(records, []string{"zscore", "mad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(report.Anomalies); i++ {
+		if report.Anomalies[i].Index <= report.Anomalies[i-1].Index {
+			t.Fatal("anomalies aren't in record order")
+		}
+	}
+	for _, anomaly := range report.Anomalies {
+		if anomaly.Index == outlier {
+			if len(anomaly.Detections) != 2 {
+				t.Errorf("outlier has %d detections, want one from each detector", len(anomaly.Detections))
+			}
+			return
+		}
+	}
+	t.Error("outlier missing from the merged report")
+}
+
+func TestRunRejectsUnknownDetector(t *testing.T) {
+	if _, err := Run(recordsWithOutlier(), []string{"crystal-ball"}); err == nil {
+		t.Error("ran an unknown detector")
+	}
+}
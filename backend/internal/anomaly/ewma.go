@@ -0,0 +1,78 @@
+package anomaly
+
+import (
+	"math"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// EWMA control chart settings
+const (
+	ewmaLambda   = 0.2  // weight of the newest value in the chart statistic
+	ewmaLimit    = 3.0  // control limit in chart standard deviations
+	ewmaBaseline = 0.05 // weight of the newest value in the slowly moving baseline
+)
+
+func init() {
+	Register(Info{
+		Name:        "ewma",
+		Description: "EWMA control chart per feature, catches smaller sustained drifts than the point detectors",
+	}, func() Detector {
+		return ewma{warmup: defaultWindow}
+	})
+}
+
+type ewma struct {
+	warmup int // records used to seed the baseline before anything is flagged
+}
+
+// Detect runs an EWMA chart on each feature against a slowly adapting baseline mean and
+// variance. A feature is out of control when the chart leaves mean ± L·σ·sqrt(λ/(2-λ)).
+// Score is the chart's distance from the baseline in limit widths, so 1 is the limit.
+func (e ewma) Detect(records []models.Record) []Flag {
+	columns := features(records)
+	start := max(e.warmup, minDetectRecord)
+	if len(records) <= start {
+		return nil
+	}
+
+	var chart, baseline, variance [featureCount]float64
+	for f := 0; f < featureCount; f++ {
+		seed := make([]float64, 0, start)
+		for t := 1; t < start; t++ {
+			seed = append(seed, columns[t][f])
+		}
+		for _, v := range seed {
+			baseline[f] += v
+		}
+		baseline[f] /= float64(len(seed))
+		for _, v := range seed {
+			variance[f] += (v - baseline[f]) * (v - baseline[f])
+		}
+		variance[f] /= float64(len(seed) - 1)
+		chart[f] = baseline[f]
+	}
+
+	var flags []Flag
+	for t := start; t < len(records); t++ {
+		flag := Flag{Index: t}
+		for f := 0; f < featureCount; f++ {
+			x := columns[t][f]
+			chart[f] = ewmaLambda*x + (1-ewmaLambda)*chart[f]
+			limit := ewmaLimit * math.Sqrt(variance[f]*ewmaLambda/(2-ewmaLambda))
+			if limit > 0 {
+				if score := math.Abs(chart[f]-baseline[f]) / limit; score >= 1 {
+					flag.Features = append(flag.Features, featureNames[f])
+					flag.Score = math.Max(flag.Score, score)
+				}
+			}
+			diff := x - baseline[f]
+			baseline[f] += ewmaBaseline * diff
+			variance[f] = (1 - ewmaBaseline) * (variance[f] + ewmaBaseline*diff*diff)
+		}
+		if len(flag.Features) > 0 {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
@@ -0,0 +1,135 @@
+package anomaly
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Isolation forest settings from Liu, Ting and Zhou (2008)
+const (
+	forestTrees      = 100
+	forestSampleSize = 256
+	forestThreshold  = 0.65 // anomaly score above which a record is flagged, 0.5 is unremarkable
+	forestSeed       = 1    // fixed so the same records always give the same flags
+)
+
+func init() {
+	Register(Info{
+		Name:        "isolation-forest",
+		Description: "Isolation forest over spread percentage, log volume and return jointly, catches unusual combinations no single feature shows",
+	}, func() Detector {
+		return isolationForest{trees: forestTrees, sampleSize: forestSampleSize}
+	})
+}
+
+type isolationForest struct {
+	trees, sampleSize int
+}
+
+type isolationNode struct {
+	feature     int
+	split       float64
+	left, right *isolationNode
+	size        int // records reaching an external node
+}
+
+func (f isolationForest) Detect(records []models.Record) []Flag {
+	if len(records) < minDetectRecord {
+		return nil
+	}
+	// Returns start at the second record
+	points := features(records)[1:]
+	sampleSize := min(f.sampleSize, len(points))
+	heightLimit := int(math.Ceil(math.Log2(float64(sampleSize))))
+	rng := rand.New(rand.NewPCG(forestSeed, uint64(len(points))))
+
+	forest := make([]*isolationNode, f.trees)
+	for i := range forest {
+		sample := make([][featureCount]float64, sampleSize)
+		for j, k := range rng.Perm(len(points))[:sampleSize] {
+			sample[j] = points[k]
+		}
+		forest[i] = growTree(sample, 0, heightLimit, rng)
+	}
+
+	var flags []Flag
+	norm := averagePathLength(sampleSize)
+	for i, point := range points {
+		total := 0.0
+		for _, tree := range forest {
+			total += pathLength(tree, point, 0)
+		}
+		score := math.Pow(2, -total/float64(len(forest))/norm)
+		if score > forestThreshold {
+			flags = append(flags, Flag{Index: i + 1, Score: score})
+		}
+	}
+	return flags
+}
+
+// Helper function to grow an isolation tree by splitting on a random feature at a random value
+func growTree(points [][featureCount]float64, depth, heightLimit int, rng *rand.Rand) *isolationNode {
+	if depth >= heightLimit || len(points) <= 1 {
+		return &isolationNode{size: len(points)}
+	}
+
+	// Only features that still vary can split
+	var candidates []int
+	var lows, highs [featureCount]float64
+	for f := 0; f < featureCount; f++ {
+		lows[f], highs[f] = points[0][f], points[0][f]
+		for _, p := range points {
+			lows[f] = math.Min(lows[f], p[f])
+			highs[f] = math.Max(highs[f], p[f])
+		}
+		if highs[f] > lows[f] {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) == 0 {
+		return &isolationNode{size: len(points)}
+	}
+
+	feature := candidates[rng.IntN(len(candidates))]
+	split := lows[feature] + rng.Float64()*(highs[feature]-lows[feature])
+	var left, right [][featureCount]float64
+	for _, p := range points {
+		if p[feature] < split {
+			left = append(left, p)
+		} else {
+			right = append(right, p)
+		}
+	}
+	return &isolationNode{
+		feature: feature,
+		split:   split,
+		left:    growTree(left, depth+1, heightLimit, rng),
+		right:   growTree(right, depth+1, heightLimit, rng),
+	}
+}
+
+// Helper function for the depth at which a point is isolated, adjusted for the
+// records left unsplit at the external node
+func pathLength(node *isolationNode, point [featureCount]float64, depth int) float64 {
+	if node.left == nil {
+		return float64(depth) + averagePathLength(node.size)
+	}
+	if point[node.feature] < node.split {
+		return pathLength(node.left, point, depth+1)
+	}
+	return pathLength(node.right, point, depth+1)
+}
+
+// Helper function for the average path length of an unsuccessful binary search tree lookup among n points
+func averagePathLength(n int) float64 {
+	if n <= 1 {
+		return 0
+	}
+	if n == 2 {
+		return 1
+	}
+	harmonic := math.Log(float64(n-1)) + 0.5772156649
+	return 2*harmonic - 2*float64(n-1)/float64(n)
+}
@@ -0,0 +1,50 @@
+package anomaly
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Modified z-score cut-off suggested by Iglewicz and Hoaglin
+const madThreshold = 3.5
+
+func init() {
+	Register(Info{
+		Name:        "mad",
+		Description: "Modified z-score from the median and median absolute deviation of the trailing 20 records, robust to earlier outliers",
+	}, func() Detector {
+		return mad{window: defaultWindow}
+	})
+}
+
+type mad struct {
+	window int
+}
+
+func (d mad) Detect(records []models.Record) []Flag {
+	return rollingDetect(records, d.window, madThreshold, func(history []float64, x float64) float64 {
+		median := medianOf(history)
+		deviations := make([]float64, len(history))
+		for i, v := range history {
+			deviations[i] = math.Abs(v - median)
+		}
+		spread := medianOf(deviations)
+		if spread == 0 {
+			return 0
+		}
+		return 0.6745 * (x - median) / spread
+	})
+}
+
+// Helper function for the median of values, which it leaves unsorted
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
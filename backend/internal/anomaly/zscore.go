@@ -0,0 +1,42 @@
+package anomaly
+
+import (
+	"math"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Standard deviations from the trailing mean before a value is flagged
+const zScoreThreshold = 3.0
+
+func init() {
+	Register(Info{
+		Name:        "zscore",
+		Description: "Rolling z-score of each feature against the trailing 20 records",
+	}, func() Detector {
+		return zScore{window: defaultWindow}
+	})
+}
+
+type zScore struct {
+	window int
+}
+
+func (z zScore) Detect(records []models.Record) []Flag {
+	return rollingDetect(records, z.window, zScoreThreshold, func(history []float64, x float64) float64 {
+		m := 0.0
+		for _, v := range history {
+			m += v
+		}
+		m /= float64(len(history))
+		ss := 0.0
+		for _, v := range history {
+			ss += (v - m) * (v - m)
+		}
+		sd := math.Sqrt(ss / float64(len(history)-1))
+		if sd == 0 {
+			return 0
+		}
+		return (x - m) / sd
+	})
+}
@@ -0,0 +1,162 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+type Config struct {
+	TrainWindow int           // records in each rolling training window
+	Horizon     int           // steps forecast from each origin
+	Stride      int           // records between successive origins, defaults to Horizon
+	Step        time.Duration // spacing of records, passed on to the forecasters
+}
+
+type FieldScore struct {
+	MAE                 float64 `json:"mae"`
+	RMSE                float64 `json:"rmse"`
+	MAPE                float64 `json:"mape"`                 // percentage, skips zero actuals
+	DirectionalAccuracy float64 `json:"directional_accuracy"` // share of steps where the forecast moved the same way as the actual
+	Observations        int     `json:"observations"`
+}
+
+type Scorecard struct {
+	Model        string     `json:"model"`
+	Folds        int        `json:"folds"`
+	BidAskSpread FieldScore `json:"bid_ask_spread"`
+	Volume       FieldScore `json:"volume"`
+	BidPrice     FieldScore `json:"bid_price"`
+	Error        string     `json:"error,omitempty"` // set when the model failed on a fold
+}
+
+// Run walks forward through records: train on TrainWindow records, forecast Horizon
+// steps, score against the records that actually followed, then slide by Stride.
+// Each model is looked up in the forecast registry and refitted on every fold.
+// Forecasts are matched to the actual with the same timestamp, so steps that fall in a
+// gap of the series (a weekend, an outage) are skipped rather than scored against a later
+// record. Scorecards are sorted by model name.
+func Run(records []models.Record, modelNames []string, cfg Config) ([]Scorecard, error) {
+	if cfg.TrainWindow < 2 || cfg.Horizon < 1 {
+		return nil, fmt.Errorf("train window must be at least 2 and horizon at least 1")
+	}
+	if cfg.Stride < 1 {
+		cfg.Stride = cfg.Horizon
+	}
+	if len(records) < cfg.TrainWindow+cfg.Horizon {
+		return nil, fmt.Errorf("need at least %d records for one fold, got %d", cfg.TrainWindow+cfg.Horizon, len(records))
+	}
+
+	names := append([]string(nil), modelNames...)
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := forecast.New(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var scorecards []Scorecard
+	for _, name := range names {
+		scorecards = append(scorecards, runModel(name, records, cfg))
+	}
+	return scorecards, nil
+}
+
+// Helper function to backtest a single model across every fold
+func runModel(name string, records []models.Record, cfg Config) Scorecard {
+	scorecard := Scorecard{Model: name}
+	var spread, volume, bidPrice accumulator
+
+	for origin := cfg.TrainWindow; origin+cfg.Horizon <= len(records); origin += cfg.Stride {
+		history := records[origin-cfg.TrainWindow : origin]
+		actuals := records[origin : origin+cfg.Horizon]
+
+		predictions, _, err := forecast.Run(name, history, forecast.Options{Steps: cfg.Horizon, Step: cfg.Step})
+		if err != nil {
+			scorecard.Error = fmt.Sprintf("fold at %s: %v", actuals[0].Timestamp, err)
+			break
+		}
+		scorecard.Folds++
+
+		// With gaps the horizon covers fewer records, so every actual it does cover is in this slice
+		byTime := make(map[int64]models.Record, len(actuals))
+		for _, actual := range actuals {
+			byTime[gridTime(actual.Timestamp, cfg.Step)] = actual
+		}
+		last := history[len(history)-1]
+		for _, predicted := range predictions {
+			actual, ok := byTime[gridTime(predicted.Timestamp, cfg.Step)]
+			if !ok {
+				continue
+			}
+			spread.add(last.BidAskSpread, predicted.BidAskSpread, actual.BidAskSpread)
+			volume.add(last.Volume, predicted.Volume, actual.Volume)
+			bidPrice.add(last.BidPrice, predicted.BidPrice, actual.BidPrice)
+		}
+	}
+
+	scorecard.BidAskSpread = spread.score()
+	scorecard.Volume = volume.score()
+	scorecard.BidPrice = bidPrice.score()
+	return scorecard
+}
+
+// Helper function to key a timestamp by the step bucket it falls in, as stats.Resample does
+func gridTime(t time.Time, step time.Duration) int64 {
+	if step > 0 {
+		t = t.Truncate(step)
+	}
+	return t.UnixNano()
+}
+
+// accumulator collects forecast errors for one field
+type accumulator struct {
+	absErr, sqErr, absPctErr float64
+	pctCount, hits, count    int
+}
+
+func (a *accumulator) add(last, predicted, actual float64) {
+	err := predicted - actual
+	a.absErr += math.Abs(err)
+	a.sqErr += err * err
+	if actual != 0 {
+		a.absPctErr += math.Abs(err / actual)
+		a.pctCount++
+	}
+	if sign(predicted-last) == sign(actual-last) {
+		a.hits++
+	}
+	a.count++
+}
+
+func (a accumulator) score() FieldScore {
+	if a.count == 0 {
+		return FieldScore{}
+	}
+	n := float64(a.count)
+	score := FieldScore{
+		MAE:                 a.absErr / n,
+		RMSE:                math.Sqrt(a.sqErr / n),
+		DirectionalAccuracy: float64(a.hits) / n,
+		Observations:        a.count,
+	}
+	if a.pctCount > 0 {
+		score.MAPE = a.absPctErr / float64(a.pctCount) * 100
+	}
+	return score
+}
+
+// Helper function returning -1, 0 or 1
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -40,10 +40,14 @@ func FetchGPTResponse(report models.LiquidityReport) (ChatCompletionResponse, er
 		return ChatCompletionResponse{}, fmt.Errorf("OPENAI_API_KEY not found in environment variables")
 	}
 
+	summary, err := json.MarshalIndent(summarise(report), "\t\t", "  ")
+	if err != nil {
+		return ChatCompletionResponse{}, fmt.Errorf("error marshalling report summary: %v", err)
+	}
 	prompt := fmt.Sprintf(
 		`You are a financial risk management expert analyzing the following Liquidity Report:
 			
-		%v
+		%s
 		
 		Context:
 		- StableTide is a tool used by financial institutions to monitor and manage liquidity risks for tokenized assets created and issued by the institution on a private blockchain.
@@ -65,7 +69,7 @@ func FetchGPTResponse(report models.LiquidityReport) (ChatCompletionResponse, er
 		- Leave out the backtick backtick backtick html...
 	
 		Focus on delivering practical, actionable, and blockchain-specific insights. Each point should not be more than 2 sentences.`,
-		report,
+		summary,
 	)
 	requestBody := ChatCompletionRequest{
 		Messages: []Message{
@@ -0,0 +1,74 @@
+package chatgpt
+
+import (
+	"fmt"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Most recent warnings of each kind the model is shown, older ones only add length
+const maxPromptWarnings = 10
+
+// reportSummary is the part of a LiquidityReport the model is prompted with. Per-record
+// detail such as the risk events, regime transitions and score components is left out,
+// so the prompt stays about the same size however much history the report covers.
+type reportSummary struct {
+	AssetType                string         `json:"asset_type"`
+	HistoricalRecords        int            `json:"historical_records"`
+	PredictionRecords        int            `json:"prediction_records"`
+	CurrentHighRisk          int            `json:"current_high_risk"`
+	PredictedHighRisk        int            `json:"predicted_high_risk"`
+	CurrentModerateRisk      int            `json:"current_moderate_risk"`
+	PredictedModerateRisk    int            `json:"predicted_moderate_risk"`
+	PossibleHighRisk         int            `json:"possible_high_risk,omitempty"`
+	CurrentWarnings          []string       `json:"current_warnings,omitempty"`
+	PredictedWarnings        []string       `json:"predicted_warnings,omitempty"`
+	PossibleHighRiskWarnings []string       `json:"possible_high_risk_warnings,omitempty"`
+	VolatilityRegime         string         `json:"volatility_regime,omitempty"`
+	LiquidityRegime          string         `json:"liquidity_regime,omitempty"`
+	ChangePoints             []string       `json:"change_points,omitempty"`
+	MetricWarnings           []string       `json:"metric_warnings,omitempty"`
+	LVaR                     float64        `json:"lvar,omitempty"` // historical, as a fraction of position value
+	Score                    float64        `json:"score"`
+	ScoreLevel               string         `json:"score_level,omitempty"`
+	Anomalies                map[string]int `json:"anomalies,omitempty"` // records flagged by each detector
+}
+
+func summarise(report models.LiquidityReport) reportSummary {
+	summary := reportSummary{
+		AssetType:                report.AssetType,
+		HistoricalRecords:        report.HistoricalRecords,
+		PredictionRecords:        report.PredictionRecords,
+		CurrentHighRisk:          report.CurrentHighRiskCount,
+		PredictedHighRisk:        report.PredictedHighRiskCount,
+		CurrentModerateRisk:      report.CurrentModerateRiskCount,
+		PredictedModerateRisk:    report.PredictedModerateRiskCount,
+		PossibleHighRisk:         report.PredictedPossibleHighRiskCount,
+		CurrentWarnings:          latest(report.CurrentWarnings),
+		PredictedWarnings:        latest(report.PredictedWarnings),
+		PossibleHighRiskWarnings: latest(report.PossibleHighRiskWarnings),
+		VolatilityRegime:         report.Volatility.Regime,
+		LiquidityRegime:          report.Regime.CurrentRegime,
+		MetricWarnings:           report.MetricWarnings,
+		LVaR:                     report.LVaR.Historical.LVaR,
+		Score:                    report.Score.Score,
+		ScoreLevel:               report.Score.Level,
+		Anomalies:                report.Anomalies.Counts,
+	}
+	describe := func(field string, points []models.ChangePoint) {
+		for _, point := range points {
+			summary.ChangePoints = append(summary.ChangePoints, fmt.Sprintf("%s %+.0f%% from %s", field, point.Change*100, point.Timestamp.Format("2006-01-02")))
+		}
+	}
+	describe("spread", report.ChangePoints.BidAskSpread)
+	describe("volume", report.ChangePoints.Volume)
+	return summary
+}
+
+// Helper function for the last maxPromptWarnings entries
+func latest(lines []string) []string {
+	if len(lines) > maxPromptWarnings {
+		return lines[len(lines)-maxPromptWarnings:]
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+func TestSummariseStaysCompact(t *testing.T) {
+	report := models.LiquidityReport{
+		AssetType:            "TEST",
+		CurrentHighRiskCount: 500,
+		Regime: models.RegimeReport{
+			CurrentRegime: "thin",
+			Transitions:   map[string]map[string]float64{"thin": {"normal": 0.1}},
+		},
+		ChangePoints: models.ChangePointReport{
+			BidAskSpread: []models.ChangePoint{{Timestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Change: 1.5, Adverse: true}},
+		},
+		Score: models.RiskScore{Score: 42, Level: "moderate"},
+	}
+	for i := 0; i < 500; i++ {
+		report.CurrentWarnings = append(report.CurrentWarnings, fmt.Sprintf("warning %d", i))
+		report.Events = append(report.Events, models.RiskEvent{AssetType: "TEST", Severity: "moderate"})
+	}
+
+	summary := summarise(report)
+	if len(summary.CurrentWarnings) != maxPromptWarnings || summary.CurrentWarnings[maxPromptWarnings-1] != "warning 499" {
+		t.Errorf("kept %d warnings ending %q, want the latest %d", len(summary.CurrentWarnings), summary.CurrentWarnings[len(summary.CurrentWarnings)-1], maxPromptWarnings)
+	}
+	if summary.CurrentHighRisk != 500 || summary.LiquidityRegime != "thin" || summary.ScoreLevel != "moderate" {
+		t.Errorf("summary lost the headline figures: %+v", summary)
+	}
+	if len(summary.ChangePoints) != 1 || summary.ChangePoints[0] != "spread +150% from 2024-03-01" {
+		t.Errorf("change points %q", summary.ChangePoints)
+	}
+
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"events", "transitions", "components"} {
+		if strings.Contains(string(encoded), field) {
+			t.Errorf("summary includes %s", field)
+		}
+	}
+	if len(encoded) > 2000 {
+		t.Errorf("summary is %d bytes for 500 events, want it bounded", len(encoded))
+	}
+}
@@ -0,0 +1,266 @@
+package correlation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Defaults for spike detection
+const (
+	spikeRise        = 0.3 // rise in average pairwise correlation over its median that counts as a spike
+	stressSpreadRise = 1.2 // basket spreads this multiple of their medians count as stress
+)
+
+type Config struct {
+	Step   time.Duration // common grid the assets are aligned on
+	Window int           // observations in each rolling correlation window
+	Stride int           // observations between rolling windows, defaults to 1
+	MaxLag int           // furthest lag checked for lead-lag relationships
+}
+
+// Rolling is the correlation matrix of one window, stamped with its last observation
+type Rolling struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Matrix    [][]float64 `json:"matrix"`
+	Average   float64     `json:"average"` // mean of the off-diagonal correlations
+}
+
+// LeadLag is the strongest cross-correlation between two assets at a non-zero lag.
+// A positive Lag means Leader's changes are followed by Follower's Lag steps later.
+type LeadLag struct {
+	Leader          string  `json:"leader"`
+	Follower        string  `json:"follower"`
+	Lag             int     `json:"lag"`
+	Correlation     float64 `json:"correlation"`
+	Contemporaneous float64 `json:"contemporaneous"` // correlation at lag 0 for comparison
+	Significant     bool    `json:"significant"`     // beyond 2/sqrt(n), roughly 95% under no correlation
+}
+
+// Spike is a window whose average correlation jumped well above its usual level.
+// Stressed spikes happened while basket spreads were wide, the contagion signal.
+type Spike struct {
+	Timestamp time.Time `json:"timestamp"`
+	Average   float64   `json:"average"`
+	Baseline  float64   `json:"baseline"` // median average correlation across windows
+	Stressed  bool      `json:"stressed"`
+}
+
+// FieldCorrelation holds the analysis of one field's changes
+type FieldCorrelation struct {
+	Overall [][]float64 `json:"overall"` // whole period
+	Rolling []Rolling   `json:"rolling"`
+	LeadLag []LeadLag   `json:"lead_lag"`
+	Spikes  []Spike     `json:"spikes"`
+}
+
+type Result struct {
+	Assets       []string         `json:"assets"` // order of the matrix rows and columns
+	Observations int              `json:"observations"`
+	BidAskSpread FieldCorrelation `json:"bid_ask_spread"` // changes in spread percentage
+	Volume       FieldCorrelation `json:"volume"`         // log changes in volume
+}
+
+// Analyse aligns each asset's records on a grid of cfg.Step, keeping only times every
+// asset traded, then correlates period-on-period changes in spread percentage and log
+// volume across assets
+func Analyse(records map[string][]models.Record, cfg Config) (Result, error) {
+	if len(records) < 2 {
+		return Result{}, fmt.Errorf("need at least 2 assets")
+	}
+	if cfg.Window < 3 {
+		return Result{}, fmt.Errorf("window must be at least 3")
+	}
+	if cfg.Stride < 1 {
+		cfg.Stride = 1
+	}
+
+	assets := make([]string, 0, len(records))
+	for asset := range records {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	timestamps, aligned := align(records, assets, cfg.Step)
+	if len(timestamps) < cfg.Window+1 {
+		return Result{}, fmt.Errorf("need at least %d common periods, got %d", cfg.Window+1, len(timestamps))
+	}
+
+	spreads := make([][]float64, len(assets))
+	spreadChanges := make([][]float64, len(assets))
+	volumeChanges := make([][]float64, len(assets))
+	for a, series := range aligned {
+		for t, record := range series {
+			spreads[a] = append(spreads[a], record.BidAskSpread/record.BidPrice)
+			if t > 0 {
+				prev := series[t-1]
+				spreadChanges[a] = append(spreadChanges[a], record.BidAskSpread/record.BidPrice-prev.BidAskSpread/prev.BidPrice)
+				volumeChanges[a] = append(volumeChanges[a], math.Log1p(record.Volume)-math.Log1p(prev.Volume))
+			}
+		}
+	}
+
+	// Changes start at the second timestamp
+	changeTimes := timestamps[1:]
+	stressed := stress(spreads, cfg.Window)
+	return Result{
+		Assets:       assets,
+		Observations: len(changeTimes),
+		BidAskSpread: analyseField(assets, changeTimes, spreadChanges, stressed, cfg),
+		Volume:       analyseField(assets, changeTimes, volumeChanges, stressed, cfg),
+	}, nil
+}
+
+// Helper function to run every analysis on one field
+func analyseField(assets []string, times []time.Time, changes [][]float64, stressed []bool, cfg Config) FieldCorrelation {
+	field := FieldCorrelation{Overall: matrix(changes, 0, len(times))}
+
+	for end := cfg.Window; end <= len(times); end += cfg.Stride {
+		m := matrix(changes, end-cfg.Window, end)
+		field.Rolling = append(field.Rolling, Rolling{Timestamp: times[end-1], Matrix: m, Average: offDiagonalMean(m)})
+	}
+
+	averages := make([]float64, len(field.Rolling))
+	for i, r := range field.Rolling {
+		averages[i] = r.Average
+	}
+	baseline := stats.Quantile(averages, 0.5)
+	for i, r := range field.Rolling {
+		if r.Average-baseline >= spikeRise {
+			// stressed is indexed by change, the window's last change is at index end-1
+			end := cfg.Window + i*cfg.Stride
+			field.Spikes = append(field.Spikes, Spike{Timestamp: r.Timestamp, Average: r.Average, Baseline: baseline, Stressed: stressed[end-1]})
+		}
+	}
+
+	n := len(times)
+	for a := 0; a < len(assets); a++ {
+		for b := a + 1; b < len(assets); b++ {
+			best := LeadLag{Leader: assets[a], Follower: assets[b], Contemporaneous: pearson(changes[a], changes[b])}
+			for lag := 1; lag <= cfg.MaxLag && lag < n-2; lag++ {
+				// a leads b: a[t] against b[t+lag]
+				if c := pearson(changes[a][:n-lag], changes[b][lag:]); math.Abs(c) > math.Abs(best.Correlation) {
+					best.Leader, best.Follower, best.Lag, best.Correlation = assets[a], assets[b], lag, c
+				}
+				if c := pearson(changes[b][:n-lag], changes[a][lag:]); math.Abs(c) > math.Abs(best.Correlation) {
+					best.Leader, best.Follower, best.Lag, best.Correlation = assets[b], assets[a], lag, c
+				}
+			}
+			if best.Lag > 0 {
+				best.Significant = math.Abs(best.Correlation) > 2/math.Sqrt(float64(n-best.Lag))
+				field.LeadLag = append(field.LeadLag, best)
+			}
+		}
+	}
+	return field
+}
+
+// Helper function to resample each asset onto the step grid and keep only timestamps
+// every asset has, returning the series in the order of assets
+func align(records map[string][]models.Record, assets []string, step time.Duration) ([]time.Time, [][]models.Record) {
+	counts := map[time.Time]int{}
+	resampled := make([][]models.Record, len(assets))
+	for a, asset := range assets {
+		for _, record := range stats.Resample(records[asset], step) {
+			if record.BidPrice > 0 {
+				resampled[a] = append(resampled[a], record)
+				counts[record.Timestamp]++
+			}
+		}
+	}
+
+	var timestamps []time.Time
+	for t, count := range counts {
+		if count == len(assets) {
+			timestamps = append(timestamps, t)
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+
+	common := map[time.Time]bool{}
+	for _, t := range timestamps {
+		common[t] = true
+	}
+	aligned := make([][]models.Record, len(assets))
+	for a, series := range resampled {
+		for _, record := range series {
+			if common[record.Timestamp] {
+				aligned[a] = append(aligned[a], record)
+			}
+		}
+	}
+	return timestamps, aligned
+}
+
+// Helper function to mark each change as stressed when the basket's spreads over the
+// trailing window average well above their medians. Index i is the change into
+// timestamp i+1.
+func stress(spreads [][]float64, window int) []bool {
+	medians := make([]float64, len(spreads))
+	for a, series := range spreads {
+		medians[a] = stats.Quantile(series, 0.5)
+	}
+	n := len(spreads[0])
+	stressed := make([]bool, n-1)
+	for t := 1; t < n; t++ {
+		ratio, count := 0.0, 0
+		for a, series := range spreads {
+			if medians[a] > 0 {
+				ratio += stats.Mean(series[max(t+1-window, 0):t+1]) / medians[a]
+				count++
+			}
+		}
+		stressed[t-1] = count > 0 && ratio/float64(count) > stressSpreadRise
+	}
+	return stressed
+}
+
+// Helper function for the correlation matrix of changes over [from, to)
+func matrix(changes [][]float64, from, to int) [][]float64 {
+	m := make([][]float64, len(changes))
+	for a := range changes {
+		m[a] = make([]float64, len(changes))
+		m[a][a] = 1
+	}
+	for a := range changes {
+		for b := a + 1; b < len(changes); b++ {
+			c := pearson(changes[a][from:to], changes[b][from:to])
+			m[a][b], m[b][a] = c, c
+		}
+	}
+	return m
+}
+
+func offDiagonalMean(m [][]float64) float64 {
+	sum, count := 0.0, 0
+	for a := range m {
+		for b := a + 1; b < len(m); b++ {
+			sum += m[a][b]
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// Helper function for the Pearson correlation of two equal length series, 0 when either is flat
+func pearson(x, y []float64) float64 {
+	mx, my := stats.Mean(x), stats.Mean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
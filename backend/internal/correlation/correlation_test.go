@@ -0,0 +1,88 @@
+package correlation
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Helper function for two assets whose volume moves follow each other lag days apart,
+// with independent spreads
+func laggedAssets(n, lag int) map[string][]models.Record {
+	rng := rand.New(rand.NewPCG(1, 1))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	volumes := make([]float64, n+lag)
+	volume := 1000.0
+	for i := range volumes {
+		volume *= math.Exp(0.2 * rng.NormFloat64())
+		volumes[i] = volume
+	}
+	records := map[string][]models.Record{}
+	for i := 0; i < n; i++ {
+		timestamp := start.Add(time.Duration(i) * 24 * time.Hour)
+		records["LEADER"] = append(records["LEADER"], models.Record{
+			AssetType: "LEADER", Timestamp: timestamp, BidPrice: 100,
+			BidAskSpread: 0.2 + 0.01*rng.NormFloat64(), Volume: volumes[i+lag],
+		})
+		records["FOLLOWER"] = append(records["FOLLOWER"], models.Record{
+			AssetType: "FOLLOWER", Timestamp: timestamp, BidPrice: 100,
+			BidAskSpread: 0.2 + 0.01*rng.NormFloat64(), Volume: volumes[i] * math.Exp(0.02*rng.NormFloat64()),
+		})
+	}
+	return records
+}
+
+func TestAnalyseFindsLeadLag(t *testing.T) {
+	result, err := Analyse(laggedAssets(200, 3), Config{Step: 24 * time.Hour, Window: 30, MaxLag: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Volume.LeadLag) != 1 {
+		t.Fatalf("got %d volume lead-lag pairs, want 1", len(result.Volume.LeadLag))
+	}
+	leadLag := result.Volume.LeadLag[0]
+	if leadLag.Leader != "LEADER" || leadLag.Follower != "FOLLOWER" || leadLag.Lag != 3 || !leadLag.Significant {
+		t.Errorf("volume lead-lag %+v, want LEADER leading FOLLOWER by 3, significant", leadLag)
+	}
+	if leadLag.Correlation < 0.9 || math.Abs(leadLag.Contemporaneous) > 0.3 {
+		t.Errorf("correlation %.2f at the lag and %.2f at 0, want strong and weak", leadLag.Correlation, leadLag.Contemporaneous)
+	}
+
+	for i, row := range result.Volume.Overall {
+		if math.Abs(row[i]-1) > 1e-9 {
+			t.Errorf("asset %d correlates %v with itself", i, row[i])
+		}
+	}
+	if want := 199 - 30 + 1; len(result.Volume.Rolling) != want {
+		t.Errorf("got %d rolling windows over 199 changes, want %d", len(result.Volume.Rolling), want)
+	}
+}
+
+func TestAnalyseAlignsOnCommonTimes(t *testing.T) {
+	records := laggedAssets(60, 1)
+	records["FOLLOWER"] = append(records["FOLLOWER"][:10], records["FOLLOWER"][15:]...)
+	result, err := Analyse(records, Config{Step: 24 * time.Hour, Window: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Observations != 54 {
+		t.Errorf("%d observations, want 54 changes over the 55 common days", result.Observations)
+	}
+
+	if _, err := Analyse(map[string][]models.Record{"LEADER": records["LEADER"]}, Config{Window: 10}); err == nil {
+		t.Error("analysed a single asset")
+	}
+}
+
+func TestPearson(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	if got := pearson(x, []float64{2, 4, 6, 8, 10}); math.Abs(got-1) > 1e-12 {
+		t.Errorf("pearson of a line = %v, want 1", got)
+	}
+	if got := pearson(x, []float64{5, 4, 3, 2, 1}); math.Abs(got+1) > 1e-12 {
+		t.Errorf("pearson of a falling line = %v, want -1", got)
+	}
+}
@@ -0,0 +1,45 @@
+package forecast
+
+import (
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+func init() {
+	Register(Info{
+		Name:        "arima",
+		Description: "ARIMA/SARIMA per field with orders selected by AIC",
+		Stochastic:  true,
+	}, func() Forecaster {
+		return &arima{}
+	})
+}
+
+type arima struct {
+	model stats.ARIMARecordModel
+}
+
+func (a *arima) Fit(history []models.Record, step time.Duration) error {
+	model, err := stats.FitARIMARecords(history, step)
+	if err != nil {
+		return err
+	}
+	a.model = model
+	return nil
+}
+
+func (a *arima) Predict(opts Options) ([]models.Record, error) {
+	return a.model.Predict(stats.ForecastOptions{
+		Intervals:        opts.Steps,
+		Step:             opts.Step,
+		Stochastic:       opts.Stochastic,
+		Seed:             opts.Seed,
+		ConfidenceLevels: opts.ConfidenceLevels,
+	}), nil
+}
+
+func (a *arima) FitSummary() any {
+	return a.model.Fit()
+}
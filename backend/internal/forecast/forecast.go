@@ -0,0 +1,78 @@
+package forecast
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/registry"
+)
+
+// Options controls a single call to Predict
+type Options struct {
+	Steps            int
+	Step             time.Duration // spacing between forecasted records
+	Stochastic       bool          // sample a noisy path instead of the expected one, if supported
+	Seed             uint64
+	ConfidenceLevels []float64 // prediction intervals to attach, if supported
+}
+
+// Forecaster is a model that learns from historical records and forecasts the ones that follow
+type Forecaster interface {
+	// Fit trains on history as stored. Models that need a regular series resample it to
+	// one record per step themselves.
+	Fit(history []models.Record, step time.Duration) error
+	// Predict forecasts the records after the fitted history
+	Predict(opts Options) ([]models.Record, error)
+}
+
+// FitReporter is implemented by forecasters that can describe their fitted parameters and error
+type FitReporter interface {
+	FitSummary() any
+}
+
+type Factory func() Forecaster
+
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Stochastic  bool   `json:"stochastic"` // Predict honours Options.Stochastic and Options.Seed
+}
+
+var forecasters = registry.New[Info, Forecaster]("model")
+
+// Register makes a forecaster available under info.Name. Implementations register themselves in init.
+func Register(info Info, factory Factory) {
+	forecasters.Register(info.Name, info, factory)
+}
+
+// Lookup returns the registration details for name
+func Lookup(name string) (Info, bool) {
+	return forecasters.Lookup(name)
+}
+
+// New returns a fresh, unfitted forecaster
+func New(name string) (Forecaster, error) {
+	return forecasters.New(name)
+}
+
+// Available lists every registered forecaster sorted by name
+func Available() []Info {
+	return forecasters.Available()
+}
+
+// Run fits the named forecaster on history and predicts in one go
+func Run(name string, history []models.Record, opts Options) ([]models.Record, Forecaster, error) {
+	forecaster, err := New(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := forecaster.Fit(history, opts.Step); err != nil {
+		return nil, nil, fmt.Errorf("error fitting %s: %v", name, err)
+	}
+	predictions, err := forecaster.Predict(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error predicting with %s: %v", name, err)
+	}
+	return predictions, forecaster, nil
+}
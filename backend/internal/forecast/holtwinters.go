@@ -0,0 +1,45 @@
+package forecast
+
+import (
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+func init() {
+	Register(Info{
+		Name:        "holt-winters",
+		Description: "Triple exponential smoothing with parameters optimised per field",
+		Stochastic:  true,
+	}, func() Forecaster {
+		return &holtWinters{}
+	})
+}
+
+type holtWinters struct {
+	model stats.RecordModel
+}
+
+func (hw *holtWinters) Fit(history []models.Record, step time.Duration) error {
+	model, err := stats.FitRecords(history, step)
+	if err != nil {
+		return err
+	}
+	hw.model = model
+	return nil
+}
+
+func (hw *holtWinters) Predict(opts Options) ([]models.Record, error) {
+	return hw.model.Predict(stats.ForecastOptions{
+		Intervals:        opts.Steps,
+		Step:             opts.Step,
+		Stochastic:       opts.Stochastic,
+		Seed:             opts.Seed,
+		ConfidenceLevels: opts.ConfidenceLevels,
+	}), nil
+}
+
+func (hw *holtWinters) FitSummary() any {
+	return hw.model.Fit()
+}
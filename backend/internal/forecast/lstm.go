@@ -0,0 +1,74 @@
+package forecast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+const microserviceURL = "http://localhost:5433/predict"
+
+func init() {
+	Register(Info{
+		Name:        "lstm",
+		Description: "LSTM network served by the Python AI microservice",
+	}, func() Forecaster {
+		return &lstm{}
+	})
+}
+
+// lstm defers to the AI microservice, which trains nothing per request so Fit only keeps the history
+type lstm struct {
+	history []models.Record
+	step    time.Duration
+}
+
+func (l *lstm) Fit(history []models.Record, step time.Duration) error {
+	l.history = history
+	l.step = step
+	return nil
+}
+
+func (l *lstm) Predict(opts Options) ([]models.Record, error) {
+	return GetPredictionsFromAI(l.history, int(opts.Step/time.Second), opts.Steps)
+}
+
+// GetPredictionsFromAI posts records to the AI microservice and returns its forecast
+func GetPredictionsFromAI(currentRecords []models.Record, intervalLength, intervals int) ([]models.Record, error) {
+	// Convert records to JSON
+	jsonData, err := json.Marshal(currentRecords)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling current records: %v", err)
+	}
+
+	// Send POST request to AI microservice
+	url := fmt.Sprintf("%s?time_interval_length=%d&time_intervals=%d", microserviceURL, intervalLength, intervals)
+	if intervalLength == 0 || intervals == 0 {
+		url = microserviceURL
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error sending request to AI microservice: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("AI microservice error: %s", string(body))
+	}
+
+	// Parse response
+	var predictions []models.Record
+	err = json.NewDecoder(resp.Body).Decode(&predictions)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding response from AI microservice: %v", err)
+	}
+
+	return predictions, nil
+}
@@ -0,0 +1,76 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+func init() {
+	Register(Info{
+		Name:        "naive",
+		Description: "Repeats the last observation, a baseline for the other models",
+	}, func() Forecaster {
+		return &naive{}
+	})
+}
+
+// naive treats each field as a random walk: the forecast is the last value and
+// the error grows with the square root of the horizon
+type naive struct {
+	last   models.Record
+	stdDev [3]float64 // one-step change deviation for spread, volume, bid price
+}
+
+func (n *naive) Fit(history []models.Record, step time.Duration) error {
+	history = stats.Resample(history, step)
+	if len(history) == 0 {
+		return fmt.Errorf("no records to fit")
+	}
+	n.last = history[len(history)-1]
+
+	var sumSq [3]float64
+	for i := 1; i < len(history); i++ {
+		prev, curr := history[i-1], history[i]
+		sumSq[0] += math.Pow(curr.BidAskSpread-prev.BidAskSpread, 2)
+		sumSq[1] += math.Pow(curr.Volume-prev.Volume, 2)
+		sumSq[2] += math.Pow(curr.BidPrice-prev.BidPrice, 2)
+	}
+	if len(history) > 1 {
+		for i := range sumSq {
+			n.stdDev[i] = math.Sqrt(sumSq[i] / float64(len(history)-1))
+		}
+	}
+	return nil
+}
+
+func (n *naive) Predict(opts Options) ([]models.Record, error) {
+	var predictions []models.Record
+	for i := 1; i <= opts.Steps; i++ {
+		record := models.Record{
+			AssetType:    n.last.AssetType,
+			Timestamp:    n.last.Timestamp.Add(time.Duration(i) * opts.Step),
+			BidAskSpread: n.last.BidAskSpread,
+			Volume:       n.last.Volume,
+			BidPrice:     n.last.BidPrice,
+		}
+		horizon := math.Sqrt(float64(i))
+		for _, level := range opts.ConfidenceLevels {
+			z := stats.NormalQuantile(0.5+level/2) * horizon
+			record.Intervals = append(record.Intervals, models.PredictionInterval{
+				Level:             level,
+				BidAskSpreadLower: math.Max(record.BidAskSpread-z*n.stdDev[0], 0),
+				BidAskSpreadUpper: record.BidAskSpread + z*n.stdDev[0],
+				VolumeLower:       math.Max(record.Volume-z*n.stdDev[1], 0),
+				VolumeUpper:       record.Volume + z*n.stdDev[1],
+				BidPriceLower:     math.Max(record.BidPrice-z*n.stdDev[2], 0),
+				BidPriceUpper:     record.BidPrice + z*n.stdDev[2],
+			})
+		}
+		predictions = append(predictions, record)
+	}
+	return predictions, nil
+}
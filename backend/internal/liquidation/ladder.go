@@ -0,0 +1,55 @@
+package liquidation
+
+import (
+	"math"
+	"time"
+)
+
+// LadderBucket is a band of a liquidity ladder, by the day after today a tranche is sold on
+type LadderBucket struct {
+	Label   string
+	LastDay int // last T+n day in the band, -1 for the open-ended last band
+}
+
+// LadderBuckets are the bands every liquidity ladder uses, from today (T+0) to beyond 30 days
+var LadderBuckets = []LadderBucket{
+	{"T+0", 0},
+	{"T+1", 1},
+	{"2-7 days", 7},
+	{"8-30 days", 30},
+	{">30 days", -1},
+}
+
+// LadderLabels lists the bucket labels in order
+func LadderLabels() []string {
+	labels := make([]string, len(LadderBuckets))
+	for i, bucket := range LadderBuckets {
+		labels[i] = bucket.Label
+	}
+	return labels
+}
+
+// Ladder values what the plan sells in each bucket at price, with anything it doesn't
+// sell in the last one. A tranche in period p is sold on day T+n, n = ceil(p * step in
+// days) - 1, so the first day of hourly periods is all T+0.
+func (p Plan) Ladder(price float64, step time.Duration) []float64 {
+	values := make([]float64, len(LadderBuckets))
+	last := len(LadderBuckets) - 1
+	for _, tranche := range p.Schedule {
+		elapsed := float64(tranche.Period) * step.Hours() / 24
+		day := max(int(math.Ceil(elapsed-1e-9))-1, 0)
+		values[bucketFor(day)] += tranche.Quantity * price
+	}
+	values[last] += p.Remaining * price
+	return values
+}
+
+// Helper function for the index of the bucket covering day T+n
+func bucketFor(day int) int {
+	for i, bucket := range LadderBuckets[:len(LadderBuckets)-1] {
+		if day <= bucket.LastDay {
+			return i
+		}
+	}
+	return len(LadderBuckets) - 1
+}
@@ -0,0 +1,27 @@
+package liquidation
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPlanLadderBucketsByDay(t *testing.T) {
+	// Period p is sold on day T+(p-1), so periods 8, 9 and 32 sit on the bucket edges
+	plan := Plan{Remaining: 5, Schedule: []Tranche{{Period: 1, Quantity: 1}, {Period: 2, Quantity: 2}, {Period: 8, Quantity: 3}, {Period: 9, Quantity: 4}, {Period: 32, Quantity: 6}}}
+	want := []float64{10, 20, 30, 40, 110}
+	for i, value := range plan.Ladder(10, 24*time.Hour) {
+		if math.Abs(value-want[i]) > 1e-9 {
+			t.Errorf("daily bucket %s holds %v, want %v", LadderBuckets[i].Label, value, want[i])
+		}
+	}
+
+	// The first 24 hourly periods are all sold today
+	hourly := Plan{Schedule: []Tranche{{Period: 1, Quantity: 1}, {Period: 24, Quantity: 1}, {Period: 25, Quantity: 1}}}
+	want = []float64{2, 1, 0, 0, 0}
+	for i, value := range hourly.Ladder(1, time.Hour) {
+		if value != want[i] {
+			t.Errorf("hourly bucket %s holds %v, want %v", LadderBuckets[i].Label, value, want[i])
+		}
+	}
+}
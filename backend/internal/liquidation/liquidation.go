@@ -0,0 +1,158 @@
+package liquidation
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Defaults for a plan
+const (
+	DefaultImpactCoefficient = 1.0 // square-root law coefficient, around 1 in most empirical studies
+	DefaultMaxPeriods        = 365 // give up on a plan after this many periods
+)
+
+type Config struct {
+	Position          float64       // units to sell
+	MaxParticipation  float64       // most of each period's volume to take, e.g. 0.1 for 10%
+	ImpactCoefficient float64       // 0 for the default
+	MaxPeriods        int           // 0 for the default
+	Step              time.Duration // spacing of the records, for days to exit
+}
+
+// Tranche is what the plan sells in one period
+type Tranche struct {
+	Period         int       `json:"period"`
+	Timestamp      time.Time `json:"timestamp"`
+	Quantity       float64   `json:"quantity"`
+	Remaining      float64   `json:"remaining"` // still to sell after this tranche
+	ExpectedVolume float64   `json:"expected_volume"`
+	Price          float64   `json:"price"`
+	Spread         float64   `json:"spread"`      // relative spread expected in the period
+	SpreadCost     float64   `json:"spread_cost"` // half the spread on every unit sold
+	ImpactCost     float64   `json:"impact_cost"`
+	Source         string    `json:"source"` // forecast, or typical once the forecast runs out
+}
+
+type Plan struct {
+	Position         float64   `json:"position"`
+	MaxParticipation float64   `json:"max_participation"`
+	Complete         bool      `json:"complete"`  // whether the position is fully sold within MaxPeriods
+	Remaining        float64   `json:"remaining"` // unsold units when not complete
+	PeriodsToExit    int       `json:"periods_to_exit"`
+	DaysToExit       float64   `json:"days_to_exit"`
+	MarketValue      float64   `json:"market_value"` // position at the last observed bid price
+	SpreadCost       float64   `json:"spread_cost"`
+	ImpactCost       float64   `json:"impact_cost"`
+	TotalCost        float64   `json:"total_cost"`
+	CostBps          float64   `json:"cost_bps"`   // total cost in basis points of market value
+	Volatility       float64   `json:"volatility"` // per period, from history, drives the impact estimate
+	Schedule         []Tranche `json:"schedule"`
+}
+
+// Build sells the position as fast as the participation cap allows, using forecast
+// volumes, spreads and prices first, then the median volume and relative spread of
+// history at the last known price once the forecast runs out. Each tranche pays half the spread plus square-root market impact,
+// coefficient * volatility * sqrt(quantity / volume), on its traded value.
+func Build(history, predictions []models.Record, cfg Config) (Plan, error) {
+	if cfg.Position <= 0 {
+		return Plan{}, fmt.Errorf("position must be positive")
+	}
+	if cfg.MaxParticipation <= 0 || cfg.MaxParticipation > 1 {
+		return Plan{}, fmt.Errorf("max participation must be between 0 and 1")
+	}
+	if len(history) < 2 {
+		return Plan{}, fmt.Errorf("need at least 2 historical records")
+	}
+	if cfg.ImpactCoefficient == 0 {
+		cfg.ImpactCoefficient = DefaultImpactCoefficient
+	}
+	if cfg.MaxPeriods == 0 {
+		cfg.MaxPeriods = DefaultMaxPeriods
+	}
+	if cfg.Step <= 0 {
+		cfg.Step = 24 * time.Hour
+	}
+
+	last := history[len(history)-1]
+	lastPrice := last.BidPrice
+	if len(predictions) > 0 {
+		lastPrice = predictions[len(predictions)-1].BidPrice
+	}
+	typical := typicalRecord(history, lastPrice)
+	prices := make([]float64, len(history))
+	for i, record := range history {
+		prices[i] = record.BidPrice
+	}
+	volatility := stats.StdDev(stats.LogReturns(prices))
+
+	plan := Plan{
+		Position:         cfg.Position,
+		MaxParticipation: cfg.MaxParticipation,
+		MarketValue:      cfg.Position * last.BidPrice,
+		Volatility:       volatility,
+	}
+	remaining := cfg.Position
+	for period := 1; period <= cfg.MaxPeriods && remaining > 0; period++ {
+		record, source := typical, "typical"
+		record.Timestamp = last.Timestamp.Add(time.Duration(period) * cfg.Step)
+		if period <= len(predictions) {
+			record, source = predictions[period-1], "forecast"
+		}
+
+		quantity := math.Min(remaining, cfg.MaxParticipation*record.Volume)
+		if quantity <= 0 || record.BidPrice <= 0 {
+			continue
+		}
+		remaining -= quantity
+		spread := record.BidAskSpread / record.BidPrice
+		value := quantity * record.BidPrice
+		tranche := Tranche{
+			Period:         period,
+			Timestamp:      record.Timestamp,
+			Quantity:       quantity,
+			Remaining:      remaining,
+			ExpectedVolume: record.Volume,
+			Price:          record.BidPrice,
+			Spread:         spread,
+			SpreadCost:     value * spread / 2,
+			ImpactCost:     value * cfg.ImpactCoefficient * volatility * math.Sqrt(quantity/record.Volume),
+			Source:         source,
+		}
+		plan.Schedule = append(plan.Schedule, tranche)
+		plan.SpreadCost += tranche.SpreadCost
+		plan.ImpactCost += tranche.ImpactCost
+		plan.PeriodsToExit = period
+	}
+
+	plan.Complete = remaining <= 0
+	plan.Remaining = math.Max(remaining, 0)
+	plan.DaysToExit = float64(plan.PeriodsToExit) * cfg.Step.Hours() / 24
+	plan.TotalCost = plan.SpreadCost + plan.ImpactCost
+	if plan.MarketValue > 0 {
+		plan.CostBps = plan.TotalCost / plan.MarketValue * 10000
+	}
+	return plan, nil
+}
+
+// Helper function for the median volume and relative spread of history at the given
+// price, used past the forecast
+func typicalRecord(history []models.Record, price float64) models.Record {
+	volumes := make([]float64, 0, len(history))
+	var spreads []float64
+	for _, record := range history {
+		volumes = append(volumes, record.Volume)
+		if record.BidPrice > 0 {
+			spreads = append(spreads, record.BidAskSpread/record.BidPrice)
+		}
+	}
+	return models.Record{
+		AssetType:    history[0].AssetType,
+		BidAskSpread: stats.Quantile(spreads, 0.5) * price,
+		Volume:       stats.Quantile(volumes, 0.5),
+		BidPrice:     price,
+	}
+}
@@ -0,0 +1,89 @@
+package liquidation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Helper function for n daily records at a constant price, spread and volume
+func flatHistory(n int, volume float64) []models.Record {
+	records := make([]models.Record, n)
+	for i := range records {
+		records[i] = models.Record{
+			AssetType:    "TEST",
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			BidAskSpread: 0.2,
+			Volume:       volume,
+			BidPrice:     100,
+		}
+	}
+	return records
+}
+
+func TestBuildStaysUnderParticipationCap(t *testing.T) {
+	history := flatHistory(30, 1000)
+	predictions := flatHistory(3, 400)
+	for i := range predictions {
+		predictions[i].Timestamp = history[len(history)-1].Timestamp.Add(time.Duration(i+1) * 24 * time.Hour)
+	}
+
+	plan, err := Build(history, predictions, Config{Position: 450, MaxParticipation: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Three forecast periods of 40, then the median historical volume allows 100 a period
+	wantQuantities := []float64{40, 40, 40, 100, 100, 100, 30}
+	if len(plan.Schedule) != len(wantQuantities) {
+		t.Fatalf("got %d tranches, want %d", len(plan.Schedule), len(wantQuantities))
+	}
+	sold := 0.0
+	for i, tranche := range plan.Schedule {
+		if tranche.Quantity > 0.1*tranche.ExpectedVolume+1e-9 {
+			t.Errorf("tranche %d sells %v of %v volume, over the 10%% cap", i, tranche.Quantity, tranche.ExpectedVolume)
+		}
+		if math.Abs(tranche.Quantity-wantQuantities[i]) > 1e-9 {
+			t.Errorf("tranche %d sells %v, want %v", i, tranche.Quantity, wantQuantities[i])
+		}
+		sold += tranche.Quantity
+	}
+	if !plan.Complete || math.Abs(sold-450) > 1e-9 || plan.PeriodsToExit != 7 || plan.DaysToExit != 7 {
+		t.Errorf("plan complete=%v, sold %v in %d periods (%v days), want 450 in 7", plan.Complete, sold, plan.PeriodsToExit, plan.DaysToExit)
+	}
+
+	// A flat price has no volatility, so the whole cost is half the 0.2% spread
+	if plan.ImpactCost != 0 || math.Abs(plan.CostBps-10) > 1e-9 {
+		t.Errorf("impact cost %v and %v bps, want 0 and 10", plan.ImpactCost, plan.CostBps)
+	}
+}
+
+func TestBuildStopsAtMaxPeriods(t *testing.T) {
+	plan, err := Build(flatHistory(30, 1000), nil, Config{Position: 1000, MaxParticipation: 0.1, MaxPeriods: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Complete || plan.Remaining != 600 {
+		t.Errorf("plan complete=%v with %v remaining, want incomplete with 600", plan.Complete, plan.Remaining)
+	}
+}
+
+func TestBuildValidatesConfig(t *testing.T) {
+	history := flatHistory(30, 1000)
+	for _, cfg := range []Config{
+		{Position: 0, MaxParticipation: 0.1},
+		{Position: 10, MaxParticipation: 0},
+		{Position: 10, MaxParticipation: 1.5},
+	} {
+		if _, err := Build(history, nil, cfg); err == nil {
+			t.Errorf("accepted %+v", cfg)
+		}
+	}
+	if _, err := Build(history[:1], nil, Config{Position: 10, MaxParticipation: 0.1}); err == nil {
+		t.Error("planned on a single record")
+	}
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+type Config struct {
+	Step   time.Duration // raw records are grouped into periods of this length, 0 means daily
+	Window int           // periods in each rolling window
+}
+
+// period holds the raw records that fall in one step
+type period struct {
+	timestamp        time.Time
+	high, low, close float64 // bid prices
+	volume           float64
+	tradedValue      float64 // sum of volume * bid price
+	spreadPercentage float64 // mean quoted spread over bid price
+}
+
+// Compute groups records into periods of cfg.Step and returns the liquidity metrics of
+// each rolling window of cfg.Window periods, stamped with the window's last period.
+// High and low prices come from the records inside each period, so the Corwin-Schultz
+// estimate needs several records per period and is 0 when there's only one.
+func Compute(records []models.Record, cfg Config) ([]models.LiquidityMetrics, error) {
+	if cfg.Window < 2 {
+		return nil, fmt.Errorf("window must be at least 2 periods")
+	}
+	periods := group(records, cfg.Step)
+	if len(periods) < cfg.Window {
+		return nil, fmt.Errorf("need at least %d periods, got %d", cfg.Window, len(periods))
+	}
+
+	series := make([]models.LiquidityMetrics, 0, len(periods)-cfg.Window+1)
+	for end := cfg.Window; end <= len(periods); end++ {
+		series = append(series, measure(periods[end-cfg.Window:end]))
+	}
+	return series, nil
+}
+
+// Overall returns the liquidity metrics over every period in records
+func Overall(records []models.Record, step time.Duration) (models.LiquidityMetrics, error) {
+	periods := group(records, step)
+	if len(periods) < 2 {
+		return models.LiquidityMetrics{}, fmt.Errorf("need at least 2 periods, got %d", len(periods))
+	}
+	return measure(periods), nil
+}
+
+// Helper function to compute every metric over a run of periods
+func measure(periods []period) models.LiquidityMetrics {
+	var amihud, spread, traded float64
+	var returns, changes []float64
+	amihudCount := 0
+	for i, p := range periods {
+		spread += p.spreadPercentage
+		traded += p.tradedValue
+		if i == 0 {
+			continue
+		}
+		prev := periods[i-1]
+		changes = append(changes, p.close-prev.close)
+		if p.close > 0 && prev.close > 0 {
+			r := math.Log(p.close / prev.close)
+			returns = append(returns, r)
+			if p.tradedValue > 0 {
+				amihud += math.Abs(r) / p.tradedValue
+				amihudCount++
+			}
+		}
+	}
+
+	n := float64(len(periods))
+	metrics := models.LiquidityMetrics{
+		Timestamp:           periods[len(periods)-1].timestamp,
+		RollSpread:          rollSpread(changes, periods),
+		CorwinSchultzSpread: corwinSchultz(periods),
+		Turnover:            traded / n,
+		RelativeSpread:      spread / n,
+		Volatility:          stddev(returns),
+	}
+	if amihudCount > 0 {
+		metrics.Amihud = amihud / float64(amihudCount)
+	}
+	return metrics
+}
+
+// Helper function for Roll's implied spread, 2*sqrt(-cov(dp[t], dp[t-1])), relative to
+// the mean price. Positive autocovariance has no implied spread and gives 0.
+func rollSpread(changes []float64, periods []period) float64 {
+	if len(changes) < 3 {
+		return 0
+	}
+	m := 0.0
+	for _, c := range changes {
+		m += c
+	}
+	m /= float64(len(changes))
+	cov := 0.0
+	for t := 1; t < len(changes); t++ {
+		cov += (changes[t] - m) * (changes[t-1] - m)
+	}
+	cov /= float64(len(changes) - 1)
+	if cov >= 0 {
+		return 0
+	}
+
+	price := 0.0
+	for _, p := range periods {
+		price += p.close
+	}
+	price /= float64(len(periods))
+	if price == 0 {
+		return 0
+	}
+	return 2 * math.Sqrt(-cov) / price
+}
+
+// Helper function for the Corwin-Schultz (2012) high-low spread estimator, averaged over
+// consecutive pairs of periods with negative estimates set to 0
+func corwinSchultz(periods []period) float64 {
+	const k = 3 - 2*math.Sqrt2
+	total, count := 0.0, 0
+	for t := 1; t < len(periods); t++ {
+		a, b := periods[t-1], periods[t]
+		if a.low <= 0 || b.low <= 0 {
+			continue
+		}
+		beta := math.Pow(math.Log(a.high/a.low), 2) + math.Pow(math.Log(b.high/b.low), 2)
+		gamma := math.Pow(math.Log(math.Max(a.high, b.high)/math.Min(a.low, b.low)), 2)
+		alpha := (math.Sqrt(2*beta)-math.Sqrt(beta))/k - math.Sqrt(gamma/k)
+		total += math.Max(0, 2*(math.Exp(alpha)-1)/(1+math.Exp(alpha)))
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// Helper function to group records into periods of length step, dropping empty periods
+func group(records []models.Record, step time.Duration) []period {
+	if step <= 0 {
+		step = 24 * time.Hour
+	}
+	sorted := append([]models.Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var periods []period
+	count := 0
+	for _, record := range sorted {
+		if record.BidPrice <= 0 {
+			continue
+		}
+		bucket := record.Timestamp.Truncate(step)
+		if len(periods) == 0 || !periods[len(periods)-1].timestamp.Equal(bucket) {
+			if count > 0 {
+				periods[len(periods)-1].spreadPercentage /= float64(count)
+			}
+			periods = append(periods, period{timestamp: bucket, high: record.BidPrice, low: record.BidPrice})
+			count = 0
+		}
+		current := &periods[len(periods)-1]
+		current.high = math.Max(current.high, record.BidPrice)
+		current.low = math.Min(current.low, record.BidPrice)
+		current.close = record.BidPrice
+		current.volume += record.Volume
+		current.tradedValue += record.Volume * record.BidPrice
+		current.spreadPercentage += record.BidAskSpread / record.BidPrice
+		count++
+	}
+	if count > 0 {
+		periods[len(periods)-1].spreadPercentage /= float64(count)
+	}
+	return periods
+}
+
+// Helper function for the sample standard deviation
+func stddev(data []float64) float64 {
+	if len(data) < 2 {
+		return 0
+	}
+	m := 0.0
+	for _, v := range data {
+		m += v
+	}
+	m /= float64(len(data))
+	sum := 0.0
+	for _, v := range data {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(data)-1))
+}
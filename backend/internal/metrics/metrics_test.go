@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+const tolerance = 1e-12
+
+func TestRollSpread(t *testing.T) {
+	// Closes bouncing between 100 and 101: changes of +1, -1, +1, -1, +1 have mean 0.2 and
+	// a first-order autocovariance of -0.96
+	var periods []period
+	for i := 0; i < 6; i++ {
+		periods = append(periods, period{close: 100 + float64(i%2)})
+	}
+	changes := []float64{1, -1, 1, -1, 1}
+	want := 2 * math.Sqrt(0.96) / 100.5
+	if got := rollSpread(changes, periods); math.Abs(got-want) > tolerance {
+		t.Fatalf("rollSpread = %v, want %v", got, want)
+	}
+
+	// A steady trend has no negative autocovariance and so no implied spread
+	if got := rollSpread([]float64{1, 1, 1, 1}, periods); got != 0 {
+		t.Fatalf("rollSpread of a trend = %v, want 0", got)
+	}
+}
+
+func TestCorwinSchultz(t *testing.T) {
+	// With two identical periods the two-day range adds nothing, alpha reduces to
+	// ln(high/low) and the spread to 2*(high/low-1)/(1+high/low) = 0.02
+	periods := []period{{high: 101, low: 99}, {high: 101, low: 99}}
+	if got := corwinSchultz(periods); math.Abs(got-0.02) > tolerance {
+		t.Fatalf("corwinSchultz = %v, want 0.02", got)
+	}
+
+	// A jump between periods is volatility, not spread, and the negative estimate is floored
+	periods = []period{{high: 100.5, low: 100}, {high: 110.5, low: 110}}
+	if got := corwinSchultz(periods); got != 0 {
+		t.Fatalf("corwinSchultz across a jump = %v, want 0", got)
+	}
+
+	// A single price per period has no range to measure
+	periods = []period{{high: 100, low: 100}, {high: 101, low: 101}}
+	if got := corwinSchultz(periods); got != 0 {
+		t.Fatalf("corwinSchultz without a range = %v, want 0", got)
+	}
+}
+
+func TestOverallAmihudAndTurnover(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []models.Record{
+		{Timestamp: start, BidPrice: 100, Volume: 10, BidAskSpread: 0.5},
+		{Timestamp: start.Add(24 * time.Hour), BidPrice: 110, Volume: 10, BidAskSpread: 1.1},
+	}
+	got, err := Overall(records, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One daily return of ln(1.1) on 1100 traded
+	if want := math.Log(1.1) / 1100; math.Abs(got.Amihud-want) > tolerance {
+		t.Errorf("Amihud = %v, want %v", got.Amihud, want)
+	}
+	if want := 1050.0; math.Abs(got.Turnover-want) > tolerance {
+		t.Errorf("Turnover = %v, want %v", got.Turnover, want)
+	}
+	if want := (0.005 + 0.01) / 2; math.Abs(got.RelativeSpread-want) > tolerance {
+		t.Errorf("RelativeSpread = %v, want %v", got.RelativeSpread, want)
+	}
+
+	if _, err := Overall(records[:1], 0); err == nil {
+		t.Error("measured a single period")
+	}
+}
+
+func TestComputeRollingWindows(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var records []models.Record
+	for i := 0; i < 10; i++ {
+		records = append(records, models.Record{Timestamp: start.Add(time.Duration(i) * 24 * time.Hour), BidPrice: 100, Volume: 1, BidAskSpread: 0.1})
+	}
+	series, err := Compute(records, Config{Window: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 7 {
+		t.Fatalf("got %d windows, want 7", len(series))
+	}
+	if !series[6].Timestamp.Equal(records[9].Timestamp) {
+		t.Errorf("last window stamped %s, want its last period %s", series[6].Timestamp, records[9].Timestamp)
+	}
+	if _, err := Compute(records, Config{Window: 11}); err == nil {
+		t.Error("computed a window longer than the series")
+	}
+}
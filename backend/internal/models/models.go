@@ -9,6 +9,54 @@ type Record struct {
 	BidAskSpread float64   `json:"bid_ask_spread"` // Difference between ask and bid prices
 	Volume       float64   `json:"volume"`         // Trading volume
 	BidPrice     float64   `json:"bid_price"`      // High price (useful for trend analysis)
+
+	Intervals []PredictionInterval `gorm:"-" json:"intervals,omitempty"` // Forecast bounds, only set on predictions
+}
+
+// Portfolio is a named set of holdings
+type Portfolio struct {
+	ID       uint      `gorm:"primaryKey" json:"-"`
+	Name     string    `gorm:"uniqueIndex" json:"name"`
+	Holdings []Holding `gorm:"constraint:OnDelete:CASCADE" json:"holdings"`
+}
+
+type Holding struct {
+	ID          uint    `gorm:"primaryKey" json:"-"`
+	PortfolioID uint    `gorm:"index" json:"-"`
+	AssetType   string  `json:"asset_type"`
+	Quantity    float64 `json:"quantity"` // units held
+}
+
+// Scenario is a named set of shocks for stress testing
+type Scenario struct {
+	ID          uint    `gorm:"primaryKey" json:"-"`
+	Name        string  `gorm:"uniqueIndex" json:"name"`
+	Description string  `json:"description,omitempty"`
+	Shocks      []Shock `gorm:"constraint:OnDelete:CASCADE" json:"shocks"`
+}
+
+// Shock changes one field of the series over a window counted in days from the start
+// of the scenario
+type Shock struct {
+	ID         uint    `gorm:"primaryKey" json:"-"`
+	ScenarioID uint    `gorm:"index" json:"-"`
+	Assets     string  `json:"assets,omitempty"` // asset type or prefix such as Crypto_, empty for every asset
+	Field      string  `json:"field"`            // bid_ask_spread, volume or bid_price
+	Operation  string  `json:"operation"`        // multiply or add
+	Value      float64 `json:"value"`
+	StartDay   float64 `json:"start_day"`
+	Days       float64 `json:"days,omitempty"` // 0 lasts to the end of the series
+}
+
+// PredictionInterval bounds a forecasted record at a given confidence level
+type PredictionInterval struct {
+	Level             float64 `json:"level"` // e.g. 0.95
+	BidAskSpreadLower float64 `json:"bid_ask_spread_lower"`
+	BidAskSpreadUpper float64 `json:"bid_ask_spread_upper"`
+	VolumeLower       float64 `json:"volume_lower"`
+	VolumeUpper       float64 `json:"volume_upper"`
+	BidPriceLower     float64 `json:"bid_price_lower"`
+	BidPriceUpper     float64 `json:"bid_price_upper"`
 }
 
 type LiquidityReport struct {
@@ -24,6 +72,229 @@ type LiquidityReport struct {
 	PredictedHighRiskCount     int      `json:"predicted_high_risk_count"`
 	CurrentModerateRiskCount   int      `json:"current_moderate_risk_count"`
 	PredictedModerateRiskCount int      `json:"predicted_moderate_risk_count"`
+
+	// Every flagged record as structured data, in time order. The warnings above
+	// describe the same high risk events as text.
+	Events []RiskEvent `json:"events"`
+
+	// Predictions whose point estimate looks fine but whose interval bound crosses the high risk thresholds
+	BoundLevel                     float64  `json:"bound_level,omitempty"`
+	PredictedPossibleHighRiskCount int      `json:"predicted_possible_high_risk_count"`
+	PossibleHighRiskWarnings       []string `json:"possible_high_risk_warnings"`
+
+	// The models below are fitted only when the assessment asks for them, /report and
+	// /recommendations fill them all
+	Volatility VolatilityReport `json:"volatility"`
+	Regime     RegimeReport     `json:"regime"`
+
+	ChangePoints ChangePointReport `json:"change_points"`
+
+	// Zero unless liquidity metrics are passed in to the assessment
+	Metrics        LiquidityMetrics `json:"metrics"` // latest rolling window
+	MetricWarnings []string         `json:"metric_warnings,omitempty"`
+
+	LVaR LVaRReport `json:"lvar"`
+
+	Policy RiskPolicy `json:"policy"` // thresholds the assessment applied
+
+	Anomalies AnomalyReport `json:"anomalies"` // empty unless detectors were requested
+
+	Score RiskScore `json:"score"`
+}
+
+// RiskScore is a 0-100 liquidity risk score that compares across assets regardless of
+// how much history each has
+type RiskScore struct {
+	Score      float64          `json:"score"` // weighted sum of component risks, 100 is worst
+	Level      string           `json:"level"` // low, moderate, elevated or high
+	Components []ScoreComponent `json:"components"`
+}
+
+type ScoreComponent struct {
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`        // raw measurement, see the component's description
+	Risk         float64 `json:"risk"`         // value mapped onto 0 to 1
+	Weight       float64 `json:"weight"`       // share of the score, weights sum to 1
+	Contribution float64 `json:"contribution"` // points added to the score, risk * weight * 100
+}
+
+// AnomalyReport merges the flags of several anomaly detectors
+type AnomalyReport struct {
+	Detectors []string       `json:"detectors"`
+	Counts    map[string]int `json:"counts"` // records flagged by each detector
+	Anomalies []Anomaly      `json:"anomalies"`
+}
+
+// Anomaly is a record flagged by at least one detector
+type Anomaly struct {
+	Index        int         `json:"-"` // position in the series the detectors ran on
+	Timestamp    time.Time   `json:"timestamp"`
+	AssetType    string      `json:"asset_type"`
+	IsPrediction bool        `json:"is_prediction"`
+	Detections   []Detection `json:"detections"` // one per detector that flagged it
+}
+
+type Detection struct {
+	Detector string   `json:"detector"`
+	Score    float64  `json:"score"`              // detector specific, larger is more anomalous
+	Features []string `json:"features,omitempty"` // features that stood out, when the detector can tell
+}
+
+// RiskPolicy holds the thresholds of the high and moderate risk rules. Spread multiples
+// are compared against the moving average of spread percentage, volume multiples against
+// the moving average of volume.
+type RiskPolicy struct {
+	Name                   string  `json:"name" yaml:"name"`
+	WindowSize             int     `json:"window_size" yaml:"window_size"` // records in the moving averages
+	HighSpreadMultiple     float64 `json:"high_spread_multiple" yaml:"high_spread_multiple"`
+	HighVolumeMultiple     float64 `json:"high_volume_multiple" yaml:"high_volume_multiple"`
+	ModerateSpreadMultiple float64 `json:"moderate_spread_multiple" yaml:"moderate_spread_multiple"`
+	ModerateVolumeMultiple float64 `json:"moderate_volume_multiple" yaml:"moderate_volume_multiple"`
+	MinSpread              float64 `json:"min_spread" yaml:"min_spread"` // spread percentage below which nothing is high risk
+}
+
+// PolicyOverride changes some fields of a RiskPolicy. Unset fields are nil rather than
+// zero, so a threshold such as min_spread can be overridden to 0.
+type PolicyOverride struct {
+	Name                   *string  `json:"name,omitempty" yaml:"name"`
+	WindowSize             *int     `json:"window_size,omitempty" yaml:"window_size"`
+	HighSpreadMultiple     *float64 `json:"high_spread_multiple,omitempty" yaml:"high_spread_multiple"`
+	HighVolumeMultiple     *float64 `json:"high_volume_multiple,omitempty" yaml:"high_volume_multiple"`
+	ModerateSpreadMultiple *float64 `json:"moderate_spread_multiple,omitempty" yaml:"moderate_spread_multiple"`
+	ModerateVolumeMultiple *float64 `json:"moderate_volume_multiple,omitempty" yaml:"moderate_volume_multiple"`
+	MinSpread              *float64 `json:"min_spread,omitempty" yaml:"min_spread"`
+}
+
+// LVaRReport is liquidity-adjusted Value-at-Risk in the style of Bangia et al. (1999):
+// market VaR on bid price returns plus the cost of crossing half the spread at a
+// stressed spread. All values are fractions of the position's value.
+type LVaRReport struct {
+	Confidence    float64      `json:"confidence"`
+	Horizon       int          `json:"horizon"`        // in periods of the record spacing
+	PositionValue float64      `json:"position_value"` // optional, scales the amounts below
+	Parametric    LVaREstimate `json:"parametric"`
+	Historical    LVaREstimate `json:"historical"`
+}
+
+type LVaREstimate struct {
+	MarketVaR      float64 `json:"market_var"`
+	LiquidityCost  float64 `json:"liquidity_cost"`  // half the stressed relative spread
+	LVaR           float64 `json:"lvar"`            // market VaR + liquidity cost
+	LiquidityShare float64 `json:"liquidity_share"` // share of LVaR due to liquidity
+	Amount         float64 `json:"amount"`          // LVaR * position value
+}
+
+// LiquidityMetrics are standard market microstructure liquidity measures over a window of periods
+type LiquidityMetrics struct {
+	Timestamp           time.Time `json:"timestamp"`             // last period of the window
+	Amihud              float64   `json:"amihud"`                // mean |log return| per unit of traded value
+	RollSpread          float64   `json:"roll_spread"`           // spread implied by negative autocovariance of price changes, relative to price
+	CorwinSchultzSpread float64   `json:"corwin_schultz_spread"` // relative spread estimated from period highs and lows
+	Turnover            float64   `json:"turnover"`              // traded value (volume * bid price) per period
+	RelativeSpread      float64   `json:"relative_spread"`       // mean quoted spread over bid price
+	Volatility          float64   `json:"volatility"`            // standard deviation of log returns per period
+}
+
+// ChangePointReport lists structural breaks found in the historical records
+type ChangePointReport struct {
+	Method       string        `json:"method"`         // pelt or cusum
+	BidAskSpread []ChangePoint `json:"bid_ask_spread"` // breaks in spread percentage
+	Volume       []ChangePoint `json:"volume"`
+}
+
+// ChangePoint is a lasting shift in the mean of a series
+type ChangePoint struct {
+	Timestamp time.Time `json:"timestamp"` // first record of the new segment
+	Before    float64   `json:"before"`    // mean of the preceding segment
+	After     float64   `json:"after"`     // mean of the new segment
+	Change    float64   `json:"change"`    // relative change, (after - before) / before
+	Adverse   bool      `json:"adverse"`   // spread widened or volume dropped
+}
+
+// RiskEvent is one record that tripped a risk rule
+type RiskEvent struct {
+	Timestamp        time.Time `json:"timestamp"`
+	AssetType        string    `json:"asset_type"`
+	Severity         string    `json:"severity"` // high, moderate, or possible_high when only an interval bound trips the high rule
+	Rules            []string  `json:"rules"`    // which thresholds tripped, e.g. spread_blowout, volume_collapse
+	SpreadPercentage float64   `json:"spread_percentage"`
+	SpreadMA         float64   `json:"spread_ma"`
+	Volume           float64   `json:"volume"`
+	VolumeMA         float64   `json:"volume_ma"`
+	IsPrediction     bool      `json:"is_prediction"`
+	BoundLevel       float64   `json:"bound_level,omitempty"` // interval level behind a possible_high event
+}
+
+// RegimeReport describes the liquidity regimes found by a Gaussian hidden Markov model
+type RegimeReport struct {
+	CurrentRegime        string                        `json:"current_regime"` // normal, thin, stressed, or unknown without enough data
+	CurrentProbabilities map[string]float64            `json:"current_probabilities"`
+	Transitions          map[string]map[string]float64 `json:"transitions"` // Transitions[from][to]
+	Profiles             map[string]RegimeProfile      `json:"profiles"`
+	Sequence             []RegimePoint                 `json:"sequence,omitempty"` // most likely regime per record
+}
+
+// RegimeProfile is the average market state within a regime
+type RegimeProfile struct {
+	SpreadPercentage float64 `json:"spread_percentage"`
+	LogVolume        float64 `json:"log_volume"`
+	AbsReturn        float64 `json:"abs_return"`
+}
+
+type RegimePoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Regime    string    `json:"regime"`
+}
+
+// VolatilityReport summarises GARCH(1,1) fits on bid price returns and spread changes
+type VolatilityReport struct {
+	Regime       string             `json:"regime"` // worst regime of the two series
+	BidPrice     VolatilityForecast `json:"bid_price"`
+	BidAskSpread VolatilityForecast `json:"bid_ask_spread"`
+}
+
+type VolatilityForecast struct {
+	Regime             string    `json:"regime"` // low, normal, elevated, high, or unknown without enough data
+	Omega              float64   `json:"omega"`
+	Alpha              float64   `json:"alpha"`
+	Beta               float64   `json:"beta"`
+	Persistence        float64   `json:"persistence"`
+	CurrentVolatility  float64   `json:"current_volatility"`  // one-step-ahead conditional standard deviation
+	LongRunVolatility  float64   `json:"long_run_volatility"` // unconditional standard deviation
+	VolatilityRatio    float64   `json:"volatility_ratio"`    // current over long run
+	ForecastVolatility []float64 `json:"forecast_volatility"` // conditional standard deviation per forecast step
+}
+
+// LiquidityLadder classifies holdings by how soon they could be sold at their historical
+// volume capacity, in the shape of a regulatory maturity ladder, and applies HQLA-style
+// haircuts from spread levels to give an LCR-style stock of liquid assets.
+type LiquidityLadder struct {
+	Buckets          []string    `json:"buckets"`
+	MaxParticipation float64     `json:"max_participation"` // share of daily volume that can be sold
+	Rows             []LadderRow `json:"rows"`
+	Totals           []float64   `json:"totals"` // value per bucket across holdings
+	TotalValue       float64     `json:"total_value"`
+	Level1           float64     `json:"level_1"`  // post-haircut value sellable within 30 days, per HQLA level
+	Level2A          float64     `json:"level_2a"`
+	Level2B          float64     `json:"level_2b"`
+	HQLA             float64     `json:"hqla"` // after the Level 2 caps
+	Outflows         float64     `json:"outflows,omitempty"`
+	LCR              float64     `json:"lcr,omitempty"` // HQLA over outflows, given outflows
+}
+
+type LadderRow struct {
+	AssetType       string    `json:"asset_type"`
+	Quantity        float64   `json:"quantity"`
+	Price           float64   `json:"price"`
+	Value           float64   `json:"value"`
+	DailyCapacity   float64   `json:"daily_capacity"` // units, median daily volume times participation
+	DaysToLiquidate float64   `json:"days_to_liquidate"`
+	RelativeSpread  float64   `json:"relative_spread"` // 90th percentile of daily spread over bid price
+	HQLALevel       string    `json:"hqla_level"`
+	Haircut         float64   `json:"haircut"`
+	Buckets         []float64 `json:"buckets"`    // value sellable in each bucket
+	HQLAValue       float64   `json:"hqla_value"` // post-haircut value sellable within 30 days
+	Error           string    `json:"error,omitempty"`
 }
 
 type TransactionRecord struct {
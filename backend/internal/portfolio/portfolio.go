@@ -0,0 +1,148 @@
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/liquidation"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Concentration thresholds
+const (
+	illiquidDays       = 5.0  // holdings needing longer than this to exit count as illiquid
+	concentrationShare = 0.25 // a single illiquid holding above this share of value is flagged
+)
+
+// Input is everything assessed for one holding
+type Input struct {
+	Holding models.Holding
+	Report  models.LiquidityReport // ignored when Error is set
+	Plan    liquidation.Plan
+	Price   float64 // last bid price
+	Error   string  // why the holding couldn't be assessed
+}
+
+type HoldingReport struct {
+	AssetType       string  `json:"asset_type"`
+	Quantity        float64 `json:"quantity"`
+	Price           float64 `json:"price"`
+	Value           float64 `json:"value"`  // quantity * last bid price
+	Weight          float64 `json:"weight"` // share of portfolio value
+	Score           float64 `json:"score"`
+	Level           string  `json:"level"`
+	HighRiskCount   int     `json:"high_risk_count"`
+	DaysToLiquidate float64 `json:"days_to_liquidate"`
+	Complete        bool    `json:"complete"` // whether the liquidation plan sells everything
+	LiquidationCost float64 `json:"liquidation_cost"`
+	Illiquid        bool    `json:"illiquid"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Bucket is the value that can be sold within a days-to-liquidate band
+type Bucket struct {
+	Label      string  `json:"label"`
+	Value      float64 `json:"value"`
+	Share      float64 `json:"share"`
+	Cumulative float64 `json:"cumulative"` // share sold by the end of this band
+}
+
+type Report struct {
+	Name            string          `json:"name"`
+	Value           float64         `json:"value"`
+	Score           float64         `json:"score"` // value-weighted risk score of the holdings
+	DaysToLiquidate float64         `json:"days_to_liquidate"`
+	LiquidationCost float64         `json:"liquidation_cost"`
+	Holdings        []HoldingReport `json:"holdings"` // largest first
+	Ladder          []Bucket        `json:"ladder"`
+	// Herfindahl index of holding weights, 1 when everything is in one asset
+	Concentration         float64  `json:"concentration"`
+	IlliquidShare         float64  `json:"illiquid_share"` // value needing more than 5 days to exit
+	ConcentrationWarnings []string `json:"concentration_warnings"`
+}
+
+// Aggregate weights each holding by its value and combines their risk scores and
+// liquidation plans. The ladder uses liquidation.LadderBuckets like the regulatory
+// ladder, with each plan's tranches in the bucket of the day they are sold on, so a
+// holding can span several buckets.
+func Aggregate(name string, inputs []Input, step time.Duration) Report {
+	report := Report{Name: name}
+	for _, input := range inputs {
+		report.Value += input.Holding.Quantity * input.Price
+	}
+
+	bucketValues := make([]float64, len(liquidation.LadderBuckets))
+	for _, input := range inputs {
+		value := input.Holding.Quantity * input.Price
+		holding := HoldingReport{
+			AssetType: input.Holding.AssetType,
+			Quantity:  input.Holding.Quantity,
+			Price:     input.Price,
+			Value:     value,
+			Error:     input.Error,
+		}
+		if report.Value > 0 {
+			holding.Weight = value / report.Value
+		}
+		if input.Error == "" {
+			holding.Score = input.Report.Score.Score
+			holding.Level = input.Report.Score.Level
+			holding.HighRiskCount = input.Report.HighRiskCount
+			holding.DaysToLiquidate = input.Plan.DaysToExit
+			holding.Complete = input.Plan.Complete
+			holding.LiquidationCost = input.Plan.TotalCost
+		}
+		holding.Illiquid = input.Error != "" || !holding.Complete || holding.DaysToLiquidate > illiquidDays
+
+		report.Score += holding.Weight * holding.Score
+		report.LiquidationCost += holding.LiquidationCost
+		if holding.DaysToLiquidate > report.DaysToLiquidate {
+			report.DaysToLiquidate = holding.DaysToLiquidate
+		}
+		report.Concentration += holding.Weight * holding.Weight
+		if holding.Illiquid {
+			report.IlliquidShare += holding.Weight
+			if holding.Weight > concentrationShare {
+				report.ConcentrationWarnings = append(report.ConcentrationWarnings, illiquidWarning(holding))
+			}
+		}
+
+		// Spread the holding's value over the buckets its tranches fall in, all of it
+		// beyond 30 days when it couldn't be assessed
+		last := len(bucketValues) - 1
+		if input.Error == "" {
+			for i, value := range input.Plan.Ladder(input.Price, step) {
+				bucketValues[i] += value
+			}
+		} else {
+			bucketValues[last] += value
+		}
+
+		report.Holdings = append(report.Holdings, holding)
+	}
+
+	sort.SliceStable(report.Holdings, func(i, j int) bool { return report.Holdings[i].Value > report.Holdings[j].Value })
+
+	cumulative := 0.0
+	for i, label := range liquidation.LadderLabels() {
+		bucket := Bucket{Label: label, Value: bucketValues[i]}
+		if report.Value > 0 {
+			bucket.Share = bucket.Value / report.Value
+		}
+		cumulative += bucket.Share
+		bucket.Cumulative = cumulative
+		report.Ladder = append(report.Ladder, bucket)
+	}
+	return report
+}
+
+func illiquidWarning(h HoldingReport) string {
+	switch {
+	case h.Error != "":
+		return fmt.Sprintf("%s is %.0f%% of portfolio value and couldn't be assessed: %s", h.AssetType, h.Weight*100, h.Error)
+	case !h.Complete:
+		return fmt.Sprintf("%s is %.0f%% of portfolio value and can't be fully exited within the planning horizon", h.AssetType, h.Weight*100)
+	}
+	return fmt.Sprintf("%s is %.0f%% of portfolio value and needs %.1f days to exit", h.AssetType, h.Weight*100, h.DaysToLiquidate)
+}
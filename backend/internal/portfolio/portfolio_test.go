@@ -0,0 +1,89 @@
+package portfolio
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/liquidation"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Helper function for an assessed holding sold in the given period -> quantity tranches
+func assessed(asset string, quantity, price, score float64, tranches map[int]float64) Input {
+	plan := liquidation.Plan{Position: quantity, Complete: true}
+	for period := 1; len(plan.Schedule) < len(tranches); period++ {
+		if sold, ok := tranches[period]; ok {
+			plan.Schedule = append(plan.Schedule, liquidation.Tranche{Period: period, Quantity: sold})
+			plan.PeriodsToExit = period
+			plan.DaysToExit = float64(period)
+		}
+	}
+	return Input{
+		Holding: models.Holding{AssetType: asset, Quantity: quantity},
+		Report:  models.LiquidityReport{Score: models.RiskScore{Score: score}},
+		Plan:    plan,
+		Price:   price,
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	inputs := []Input{
+		assessed("LIQUID", 100, 10, 20, map[int]float64{1: 60, 2: 40}),
+		assessed("SLOW", 15, 100, 60, map[int]float64{10: 15}),
+		{Holding: models.Holding{AssetType: "BROKEN", Quantity: 100}, Price: 20, Error: "no records"},
+	}
+	report := Aggregate("test", inputs, 24*time.Hour)
+
+	if report.Value != 4500 {
+		t.Errorf("value %v, want 4500", report.Value)
+	}
+	if want := 1000.0/4500*20 + 1500.0/4500*60; math.Abs(report.Score-want) > 1e-9 {
+		t.Errorf("score %v, want the value-weighted %v", report.Score, want)
+	}
+	if report.DaysToLiquidate != 10 {
+		t.Errorf("days to liquidate %v, want the slowest holding's 10", report.DaysToLiquidate)
+	}
+	for i, asset := range []string{"BROKEN", "SLOW", "LIQUID"} {
+		if report.Holdings[i].AssetType != asset {
+			t.Errorf("holding %d is %s, want %s, largest first", i, report.Holdings[i].AssetType, asset)
+		}
+	}
+
+	wantValues := []float64{600, 400, 0, 1500, 2000}
+	for i, bucket := range report.Ladder {
+		if math.Abs(bucket.Value-wantValues[i]) > 1e-9 {
+			t.Errorf("bucket %s holds %v, want %v", bucket.Label, bucket.Value, wantValues[i])
+		}
+	}
+	if last := report.Ladder[len(report.Ladder)-1]; math.Abs(last.Cumulative-1) > 1e-9 {
+		t.Errorf("ladder sells %v of the portfolio, want all of it", last.Cumulative)
+	}
+
+	if want := 3500.0 / 4500; math.Abs(report.IlliquidShare-want) > 1e-9 {
+		t.Errorf("illiquid share %v, want %v from SLOW and BROKEN", report.IlliquidShare, want)
+	}
+	if len(report.ConcentrationWarnings) != 2 ||
+		!strings.Contains(report.ConcentrationWarnings[0], "SLOW") ||
+		!strings.Contains(report.ConcentrationWarnings[1], "no records") {
+		t.Errorf("warnings %q, want SLOW's exit time and BROKEN's error", report.ConcentrationWarnings)
+	}
+	if want := (1000*1000 + 1500*1500 + 2000*2000) / (4500.0 * 4500); math.Abs(report.Concentration-want) > 1e-9 {
+		t.Errorf("concentration %v, want %v", report.Concentration, want)
+	}
+}
+
+func TestAggregateSmallIlliquidHolding(t *testing.T) {
+	inputs := []Input{
+		assessed("LIQUID", 90, 10, 10, map[int]float64{1: 90}),
+		assessed("SLOW", 10, 10, 80, map[int]float64{20: 10}),
+	}
+	report := Aggregate("test", inputs, 24*time.Hour)
+	if !report.Holdings[1].Illiquid {
+		t.Error("a holding needing 20 days wasn't marked illiquid")
+	}
+	if len(report.ConcentrationWarnings) != 0 {
+		t.Errorf("warned about a 10%% holding: %q", report.ConcentrationWarnings)
+	}
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds the named implementations of one pluggable part, such as forecasters or
+// anomaly detectors, with a description of each to list. It is safe for concurrent use.
+type Registry[I, T any] struct {
+	kind    string // what is registered, for errors
+	mu      sync.RWMutex
+	entries map[string]entry[I, T]
+}
+
+type entry[I, T any] struct {
+	info    I
+	factory func() T
+}
+
+// New returns an empty registry. kind names what it holds in errors, e.g. "model".
+func New[I, T any](kind string) *Registry[I, T] {
+	return &Registry[I, T]{kind: kind, entries: map[string]entry[I, T]{}}
+}
+
+// Register adds factory under name. Registering a name twice is a programming error and panics.
+func (r *Registry[I, T]) Register(name string, info I, factory func() T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.entries[name]; exists {
+		panic(fmt.Sprintf("%s %q registered twice", r.kind, name))
+	}
+	r.entries[name] = entry[I, T]{info: info, factory: factory}
+}
+
+// Lookup returns the description registered under name
+func (r *Registry[I, T]) Lookup(name string) (I, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[name]
+	return e.info, ok
+}
+
+// New calls the factory registered under name
+func (r *Registry[I, T]) New(name string) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[name]
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("unknown %s %q", r.kind, name)
+	}
+	return e.factory(), nil
+}
+
+// Available returns every description, sorted by the name it was registered under
+func (r *Registry[I, T]) Available() []I {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	infos := make([]I, len(names))
+	for i, name := range names {
+		infos[i] = r.entries[name].info
+	}
+	return infos
+}
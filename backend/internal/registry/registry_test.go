@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New[string, int]("number")
+	r.Register("two", "second", func() int { return 2 })
+	r.Register("one", "first", func() int { return 1 })
+
+	if got, err := r.New("two"); err != nil || got != 2 {
+		t.Errorf("New(two) = %v, %v", got, err)
+	}
+	if _, err := r.New("three"); err == nil || err.Error() != `unknown number "three"` {
+		t.Errorf("New(three) error = %v", err)
+	}
+	if info, ok := r.Lookup("one"); !ok || info != "first" {
+		t.Errorf("Lookup(one) = %q, %v", info, ok)
+	}
+	if got := r.Available(); !reflect.DeepEqual(got, []string{"first", "second"}) {
+		t.Errorf("Available() = %v, want sorted by name", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice didn't panic")
+		}
+	}()
+	r.Register("one", "again", func() int { return 1 })
+}
@@ -0,0 +1,69 @@
+package riskassessment
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Change-point detection methods
+const (
+	ChangePointPELT  = "pelt"
+	ChangePointCUSUM = "cusum"
+)
+
+// DetectChangePoints looks for lasting shifts in spread percentage and volume, the kind
+// of step change (e.g. a market maker leaving) that the moving average rules only flag
+// until the averages catch up. Volume is searched on a log scale so a break is judged
+// by its relative size, but Before and After are reported in raw units.
+func DetectChangePoints(records []models.Record, method string) (models.ChangePointReport, error) {
+	var detect func([]float64) []int
+	switch method {
+	case ChangePointPELT:
+		detect = func(data []float64) []int { return stats.PELT(data, 0, 0) }
+	case ChangePointCUSUM:
+		detect = func(data []float64) []int { return stats.CUSUM(data, 0, 0) }
+	default:
+		return models.ChangePointReport{}, fmt.Errorf("unknown change-point method %q, use %s or %s", method, ChangePointPELT, ChangePointCUSUM)
+	}
+
+	var spreads, volumes, logVolumes []float64
+	var spreadRecords []models.Record
+	for _, record := range records {
+		if record.BidPrice > 0 {
+			spreads = append(spreads, record.BidAskSpread/record.BidPrice)
+			spreadRecords = append(spreadRecords, record)
+		}
+		volumes = append(volumes, record.Volume)
+		logVolumes = append(logVolumes, math.Log1p(record.Volume))
+	}
+
+	return models.ChangePointReport{
+		Method:       method,
+		BidAskSpread: changePoints(spreadRecords, spreads, detect(spreads), 1),
+		Volume:       changePoints(records, volumes, detect(logVolumes), -1),
+	}, nil
+}
+
+// Helper function to describe each break. adverseSign is the direction of a change that
+// hurts liquidity: 1 when an increase is bad, -1 when a decrease is.
+func changePoints(records []models.Record, data []float64, changes []int, adverseSign float64) []models.ChangePoint {
+	means := stats.SegmentMeans(data, changes)
+	points := make([]models.ChangePoint, 0, len(changes))
+	for i, index := range changes {
+		before, after := means[i], means[i+1]
+		point := models.ChangePoint{
+			Timestamp: records[index].Timestamp,
+			Before:    before,
+			After:     after,
+			Adverse:   (after-before)*adverseSign > 0,
+		}
+		if before != 0 {
+			point.Change = (after - before) / before
+		}
+		points = append(points, point)
+	}
+	return points
+}
@@ -0,0 +1,49 @@
+package riskassessment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+func TestDetectChangePointsFindsAdverseBreaks(t *testing.T) {
+	records := testRecords(120, 4)
+	// A market maker leaves at record 60: spreads triple and volume halves
+	for i := 60; i < len(records); i++ {
+		records[i].BidAskSpread *= 3
+		records[i].Volume /= 2
+	}
+	breakAt := records[60].Timestamp
+
+	for _, method := range []string{ChangePointPELT, ChangePointCUSUM} {
+		report, err := DetectChangePoints(records, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Noisier spreads after the break can add smaller breaks later, the first is the shift
+		checkFirstBreak(t, method+" spread", report.BidAskSpread, breakAt, 1.5)
+		checkFirstBreak(t, method+" volume", report.Volume, breakAt, -0.4)
+	}
+
+	if _, err := DetectChangePoints(records, "binary"); err == nil {
+		t.Error("accepted an unknown method")
+	}
+}
+
+// Helper function to check the first break is an adverse one within a record of at,
+// moving at least minChange (at most, when negative)
+func checkFirstBreak(t *testing.T, name string, points []models.ChangePoint, at time.Time, minChange float64) {
+	t.Helper()
+	if len(points) == 0 {
+		t.Errorf("%s: no breaks found", name)
+		return
+	}
+	first := points[0]
+	if gap := first.Timestamp.Sub(at); gap < -24*time.Hour || gap > 24*time.Hour {
+		t.Errorf("%s: first break at %s, want within a day of %s", name, first.Timestamp, at)
+	}
+	if !first.Adverse || (minChange > 0 && first.Change < minChange) || (minChange < 0 && first.Change > minChange) {
+		t.Errorf("%s: first break %+v, want an adverse change beyond %v", name, first, minChange)
+	}
+}
@@ -0,0 +1,159 @@
+package riskassessment
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/liquidation"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// HQLA levels and their haircuts
+const (
+	HQLALevel1  = "level_1"
+	HQLALevel2A = "level_2a"
+	HQLALevel2B = "level_2b"
+	NonHQLA     = "non_hqla"
+)
+
+// Default share of daily volume a holding can be sold into
+const DefaultLadderParticipation = 0.1
+
+// Spread bands for each HQLA level, checked in order against the 90th percentile of the
+// relative spread. Tighter spreads mean the asset can be sold in stress with little loss.
+var hqlaLevels = []struct {
+	level     string
+	maxSpread float64
+	haircut   float64
+}{
+	{HQLALevel1, 0.001, 0},
+	{HQLALevel2A, 0.005, 0.15},
+	{HQLALevel2B, 0.02, 0.5},
+	{NonHQLA, math.Inf(1), 1},
+}
+
+type LadderConfig struct {
+	MaxParticipation float64 // 0 for the default
+	Outflows         float64 // optional 30-day net outflows for the LCR
+}
+
+// LadderHolding is a position and the records its capacity and spread are taken from
+type LadderHolding struct {
+	AssetType string
+	Quantity  float64
+	Records   []models.Record
+}
+
+// BuildLadder places each holding's value in the buckets it could be sold in. It uses the
+// same buckets and liquidation planner as the portfolio report, but plans on daily
+// history alone, so each day sells the median daily volume times the participation cap.
+// Haircuts come from the
+// spread band of the 90th percentile daily relative spread, and the HQLA stock counts
+// only value sellable within 30 days, with the Basel III caps of 40% on Level 2 and 15%
+// on Level 2B assets.
+func BuildLadder(holdings []LadderHolding, cfg LadderConfig) models.LiquidityLadder {
+	if cfg.MaxParticipation <= 0 {
+		cfg.MaxParticipation = DefaultLadderParticipation
+	}
+	ladder := models.LiquidityLadder{
+		MaxParticipation: cfg.MaxParticipation,
+		Buckets:          liquidation.LadderLabels(),
+		Totals:           make([]float64, len(liquidation.LadderBuckets)),
+		Outflows:         cfg.Outflows,
+	}
+
+	for _, holding := range holdings {
+		row := ladderRow(holding, cfg.MaxParticipation)
+		for i, value := range row.Buckets {
+			ladder.Totals[i] += value
+		}
+		ladder.TotalValue += row.Value
+		switch row.HQLALevel {
+		case HQLALevel1:
+			ladder.Level1 += row.HQLAValue
+		case HQLALevel2A:
+			ladder.Level2A += row.HQLAValue
+		case HQLALevel2B:
+			ladder.Level2B += row.HQLAValue
+		}
+		ladder.Rows = append(ladder.Rows, row)
+	}
+	sort.SliceStable(ladder.Rows, func(i, j int) bool { return ladder.Rows[i].Value > ladder.Rows[j].Value })
+
+	ladder.HQLA = cappedHQLA(ladder.Level1, ladder.Level2A, ladder.Level2B)
+	if cfg.Outflows > 0 {
+		ladder.LCR = ladder.HQLA / cfg.Outflows
+	}
+	return ladder
+}
+
+func ladderRow(holding LadderHolding, participation float64) models.LadderRow {
+	row := models.LadderRow{
+		AssetType: holding.AssetType,
+		Quantity:  holding.Quantity,
+		Buckets:   make([]float64, len(liquidation.LadderBuckets)),
+		HQLALevel: NonHQLA,
+		Haircut:   1,
+	}
+	daily := stats.Resample(holding.Records, 24*time.Hour)
+	if len(daily) == 0 {
+		row.Error = "no records in the requested range"
+		return row
+	}
+	row.Price = daily[len(daily)-1].BidPrice
+	row.Value = holding.Quantity * row.Price
+
+	volumes := make([]float64, 0, len(daily))
+	spreads := make([]float64, 0, len(daily))
+	for _, record := range daily {
+		volumes = append(volumes, record.Volume)
+		if record.BidPrice > 0 {
+			spreads = append(spreads, record.BidAskSpread/record.BidPrice)
+		}
+	}
+	row.DailyCapacity = stats.Quantile(volumes, 0.5) * participation
+	if len(spreads) > 0 {
+		row.RelativeSpread = stats.Quantile(spreads, 0.9)
+		for _, band := range hqlaLevels {
+			if row.RelativeSpread <= band.maxSpread {
+				row.HQLALevel, row.Haircut = band.level, band.haircut
+				break
+			}
+		}
+	}
+
+	last := len(row.Buckets) - 1
+	if row.DailyCapacity <= 0 {
+		row.Error = "no traded volume in the requested range"
+		row.Buckets[last] = row.Value
+		return row
+	}
+	plan, err := liquidation.Build(daily, nil, liquidation.Config{
+		Position:         holding.Quantity,
+		MaxParticipation: participation,
+		Step:             24 * time.Hour,
+	})
+	if err != nil {
+		row.Error = err.Error()
+		row.Buckets[last] = row.Value
+		return row
+	}
+	row.Buckets = plan.Ladder(row.Price, 24*time.Hour)
+	// Past the planner's horizon, estimate the exit at the same daily capacity
+	row.DaysToLiquidate = plan.DaysToExit
+	if !plan.Complete {
+		row.DaysToLiquidate = holding.Quantity / row.DailyCapacity
+	}
+	row.HQLAValue = (row.Value - row.Buckets[last]) * (1 - row.Haircut)
+	return row
+}
+
+// Helper function for the HQLA stock after the Basel III Level 2 caps: Level 2B at most
+// 15% and Level 2 as a whole at most 40% of the total
+func cappedHQLA(level1, level2A, level2B float64) float64 {
+	adjustment15 := math.Max(0, math.Max(level2B-15.0/85*(level1+level2A), level2B-15.0/60*level1))
+	adjustment40 := math.Max(0, level2A+level2B-adjustment15-2.0/3*level1)
+	return level1 + level2A + level2B - adjustment15 - adjustment40
+}
@@ -0,0 +1,94 @@
+package riskassessment
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Helper function for n days of records with a constant price, spread and volume
+func flatRecords(asset string, n int, spread, volume float64) []models.Record {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]models.Record, n)
+	for i := range records {
+		records[i] = models.Record{
+			AssetType:    asset,
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			BidPrice:     100,
+			BidAskSpread: spread,
+			Volume:       volume,
+		}
+	}
+	return records
+}
+
+func TestBuildLadder(t *testing.T) {
+	holdings := []LadderHolding{
+		{AssetType: "TIGHT", Quantity: 250, Records: flatRecords("TIGHT", 60, 0.05, 1000)},
+		{AssetType: "WIDE", Quantity: 5000, Records: flatRecords("WIDE", 60, 1, 1000)},
+	}
+	ladder := BuildLadder(holdings, LadderConfig{Outflows: 10000})
+
+	if ladder.MaxParticipation != DefaultLadderParticipation {
+		t.Errorf("participation %v, want the default", ladder.MaxParticipation)
+	}
+	wide, tight := ladder.Rows[0], ladder.Rows[1]
+	if tight.HQLALevel != HQLALevel1 || wide.HQLALevel != HQLALevel2B {
+		t.Errorf("levels %s and %s, want %s for a 5bp spread and %s for 1%%", tight.HQLALevel, wide.HQLALevel, HQLALevel1, HQLALevel2B)
+	}
+	if tight.DailyCapacity != 100 {
+		t.Errorf("daily capacity %v, want 10%% of 1000", tight.DailyCapacity)
+	}
+
+	// 100 units a day: T+0, T+1 and the rest on day 2
+	wantTight := []float64{10000, 10000, 5000, 0, 0}
+	for i, value := range tight.Buckets {
+		if math.Abs(value-wantTight[i]) > 1e-6 {
+			t.Errorf("TIGHT bucket %s holds %v, want %v", ladder.Buckets[i], value, wantTight[i])
+		}
+	}
+	// 31 days fit in the first four buckets and the remaining 1900 units go beyond 30 days
+	if last := wide.Buckets[len(wide.Buckets)-1]; math.Abs(last-190000) > 1e-6 {
+		t.Errorf("WIDE holds %v beyond 30 days, want 190000", last)
+	}
+	if wide.DaysToLiquidate != 50 {
+		t.Errorf("WIDE needs %v days, want 50", wide.DaysToLiquidate)
+	}
+
+	if ladder.Level1 != 25000 || math.Abs(ladder.Level2B-155000) > 1e-6 {
+		t.Errorf("level 1 %v and level 2B %v, want 25000 and half of the 310000 sold within 30 days", ladder.Level1, ladder.Level2B)
+	}
+	if want := 25000 / 0.85; math.Abs(ladder.HQLA-want) > 1e-6 || math.Abs(ladder.LCR-want/10000) > 1e-9 {
+		t.Errorf("HQLA %v and LCR %v, want %v after the 15%% cap", ladder.HQLA, ladder.LCR, want)
+	}
+}
+
+func TestBuildLadderWithoutVolume(t *testing.T) {
+	ladder := BuildLadder([]LadderHolding{{AssetType: "DRY", Quantity: 10, Records: flatRecords("DRY", 10, 0.05, 0)}}, LadderConfig{})
+	row := ladder.Rows[0]
+	if row.Error == "" || row.Buckets[len(row.Buckets)-1] != 1000 || ladder.HQLA != 0 {
+		t.Errorf("row %+v, want an error and all value beyond 30 days", row)
+	}
+}
+
+func TestCappedHQLA(t *testing.T) {
+	tests := []struct {
+		name                     string
+		level1, level2A, level2B float64
+		want                     float64
+	}{
+		{"under both caps", 100, 50, 10, 160},
+		{"level 2 capped at 40%", 100000, 4250000, 0, 100000 / 0.6},
+		{"level 2B capped at 15%", 100, 0, 100, 100 / 0.85},
+		{"level 1 only", 100, 0, 0, 100},
+		{"no level 1", 0, 100, 100, 0},
+	}
+	for _, test := range tests {
+		got := cappedHQLA(test.level1, test.level2A, test.level2B)
+		if math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("%s: cappedHQLA(%v, %v, %v) = %v, want %v", test.name, test.level1, test.level2A, test.level2B, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package riskassessment
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLVaRGrowsWithConfidence(t *testing.T) {
+	records := testRecords(500, 5)
+	var previous, previousHistorical float64
+	for _, confidence := range []float64{0.9, 0.95, 0.99} {
+		report, err := ComputeLVaR(records, LVaRConfig{Confidence: confidence})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Parametric.LVaR <= previous || report.Historical.LVaR <= previousHistorical {
+			t.Errorf("LVaR at %.2f = %.5f parametric, %.5f historical, want both above the lower level's %.5f, %.5f",
+				confidence, report.Parametric.LVaR, report.Historical.LVaR, previous, previousHistorical)
+		}
+		previous, previousHistorical = report.Parametric.LVaR, report.Historical.LVaR
+	}
+}
+
+func TestLVaRGrowsWithHorizon(t *testing.T) {
+	records := testRecords(500, 6)
+	daily, err := ComputeLVaR(records, LVaRConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	weekly, err := ComputeLVaR(records, LVaRConfig{Horizon: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weekly.Parametric.MarketVaR <= daily.Parametric.MarketVaR {
+		t.Errorf("5-day market VaR %.5f isn't above the 1-day %.5f", weekly.Parametric.MarketVaR, daily.Parametric.MarketVaR)
+	}
+	// The exit cost doesn't depend on how long the position is held
+	if daily.Parametric.LiquidityCost != weekly.Parametric.LiquidityCost {
+		t.Error("liquidity cost changed with the horizon")
+	}
+}
+
+func TestLVaRWithConstantPriceIsHalfTheSpread(t *testing.T) {
+	records := testRecords(50, 7)
+	for i := range records {
+		records[i].BidPrice = 100
+		records[i].BidAskSpread = 0.4
+	}
+	report, err := ComputeLVaR(records, LVaRConfig{PositionValue: 1e6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, estimate := range map[string]struct{ market, lvar, amount float64 }{
+		"parametric": {report.Parametric.MarketVaR, report.Parametric.LVaR, report.Parametric.Amount},
+		"historical": {report.Historical.MarketVaR, report.Historical.LVaR, report.Historical.Amount},
+	} {
+		if estimate.market != 0 || math.Abs(estimate.lvar-0.002) > 1e-12 || math.Abs(estimate.amount-2000) > 1e-6 {
+			t.Errorf("%s: market VaR %v, LVaR %v, amount %v, want 0, 0.002 and 2000", name, estimate.market, estimate.lvar, estimate.amount)
+		}
+	}
+}
+
+func TestLVaRRejectsBadConfig(t *testing.T) {
+	records := testRecords(50, 8)
+	for _, cfg := range []LVaRConfig{{Confidence: 1}, {Confidence: -0.5}, {Horizon: -1}, {Horizon: 60}} {
+		if _, err := ComputeLVaR(records, cfg); err == nil {
+			t.Errorf("accepted %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package riskassessment
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// How far the latest window may drift from the median window before it is flagged
+const (
+	metricWarningRatio   = 2.0 // illiquidity, implied spreads and volatility above this multiple
+	turnoverWarningRatio = 0.5 // turnover below this multiple
+)
+
+// Helper function to flag metrics in the latest window that stand out against the
+// median of every window in the series
+func metricWarnings(assetType string, series []models.LiquidityMetrics) []string {
+	latest := series[len(series)-1]
+	median := func(value func(models.LiquidityMetrics) float64) float64 {
+		values := make([]float64, len(series))
+		for i, m := range series {
+			values[i] = value(m)
+		}
+		sort.Float64s(values)
+		return values[len(values)/2]
+	}
+
+	var warnings []string
+	checks := []struct {
+		name  string
+		value func(models.LiquidityMetrics) float64
+	}{
+		{"Amihud illiquidity", func(m models.LiquidityMetrics) float64 { return m.Amihud }},
+		{"Roll implied spread", func(m models.LiquidityMetrics) float64 { return m.RollSpread }},
+		{"Corwin-Schultz spread", func(m models.LiquidityMetrics) float64 { return m.CorwinSchultzSpread }},
+		{"Volatility", func(m models.LiquidityMetrics) float64 { return m.Volatility }},
+	}
+	for _, check := range checks {
+		typical := median(check.value)
+		if current := check.value(latest); typical > 0 && current > metricWarningRatio*typical {
+			warnings = append(warnings, fmt.Sprintf("%s for %s at %s is %.1fx its median (%.4g vs %.4g)",
+				check.name, assetType, latest.Timestamp, current/typical, current, typical))
+		}
+	}
+	typical := median(func(m models.LiquidityMetrics) float64 { return m.Turnover })
+	if typical > 0 && latest.Turnover < turnoverWarningRatio*typical {
+		warnings = append(warnings, fmt.Sprintf("Turnover for %s at %s is %.1fx its median (%.4g vs %.4g)",
+			assetType, latest.Timestamp, latest.Turnover/typical, latest.Turnover, typical))
+	}
+	return warnings
+}
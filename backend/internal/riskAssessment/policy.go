@@ -0,0 +1,141 @@
+package riskassessment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPolicy is the rule set AssessLiquidity has always applied
+func DefaultPolicy() models.RiskPolicy {
+	return models.RiskPolicy{
+		Name:                   "default",
+		WindowSize:             8,
+		HighSpreadMultiple:     3.0,
+		HighVolumeMultiple:     0.4,
+		ModerateSpreadMultiple: 1.2,
+		ModerateVolumeMultiple: 0.7,
+		MinSpread:              0.0002,
+	}
+}
+
+// PolicySet selects a risk policy for an asset. Policies only need the fields they
+// change: an asset's policy is layered over the longest matching prefix policy, which
+// is layered over Default, which is layered over DefaultPolicy.
+type PolicySet struct {
+	Default  models.PolicyOverride            `json:"default" yaml:"default"`
+	Prefixes map[string]models.PolicyOverride `json:"prefixes" yaml:"prefixes"` // asset type prefix, e.g. ETF_ or Crypto_
+	Assets   map[string]models.PolicyOverride `json:"assets" yaml:"assets"`     // exact asset type, e.g. Crypto_BTC
+}
+
+// LoadPolicies reads a PolicySet from a .yaml, .yml or .json file and checks that the
+// policy every asset could resolve to is valid
+func LoadPolicies(path string) (PolicySet, error) {
+	var set PolicySet
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return set, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &set)
+	case ".json":
+		err = json.Unmarshal(data, &set)
+	default:
+		return set, fmt.Errorf("unsupported policy file %q, use .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return set, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	if err := ValidatePolicy(set.For("")); err != nil {
+		return set, fmt.Errorf("default policy: %v", err)
+	}
+	for prefix := range set.Prefixes {
+		if err := ValidatePolicy(set.For(prefix)); err != nil {
+			return set, fmt.Errorf("policy for prefix %q: %v", prefix, err)
+		}
+	}
+	for asset := range set.Assets {
+		if err := ValidatePolicy(set.For(asset)); err != nil {
+			return set, fmt.Errorf("policy for asset %q: %v", asset, err)
+		}
+	}
+	return set, nil
+}
+
+// For returns the policy that applies to an asset type. Its name records where it
+// came from when the file doesn't name it.
+func (s PolicySet) For(assetType string) models.RiskPolicy {
+	policy := MergePolicy(DefaultPolicy(), s.Default)
+
+	best := ""
+	for prefix := range s.Prefixes {
+		if strings.HasPrefix(assetType, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best != "" {
+		policy = MergePolicy(policy, named(s.Prefixes[best], "prefix "+best))
+	}
+	if override, ok := s.Assets[assetType]; ok {
+		policy = MergePolicy(policy, named(override, "asset "+assetType))
+	}
+	return policy
+}
+
+// MergePolicy returns base with every field set in override applied
+func MergePolicy(base models.RiskPolicy, override models.PolicyOverride) models.RiskPolicy {
+	if override.Name != nil {
+		base.Name = *override.Name
+	}
+	if override.WindowSize != nil {
+		base.WindowSize = *override.WindowSize
+	}
+	if override.HighSpreadMultiple != nil {
+		base.HighSpreadMultiple = *override.HighSpreadMultiple
+	}
+	if override.HighVolumeMultiple != nil {
+		base.HighVolumeMultiple = *override.HighVolumeMultiple
+	}
+	if override.ModerateSpreadMultiple != nil {
+		base.ModerateSpreadMultiple = *override.ModerateSpreadMultiple
+	}
+	if override.ModerateVolumeMultiple != nil {
+		base.ModerateVolumeMultiple = *override.ModerateVolumeMultiple
+	}
+	if override.MinSpread != nil {
+		base.MinSpread = *override.MinSpread
+	}
+	return base
+}
+
+// ValidatePolicy rejects thresholds that would make the rules meaningless
+func ValidatePolicy(p models.RiskPolicy) error {
+	switch {
+	case p.WindowSize < 1:
+		return fmt.Errorf("window_size must be at least 1")
+	case p.HighSpreadMultiple <= 1 || p.ModerateSpreadMultiple <= 1:
+		return fmt.Errorf("spread multiples must be above 1")
+	case p.HighVolumeMultiple <= 0 || p.HighVolumeMultiple >= 1 || p.ModerateVolumeMultiple <= 0 || p.ModerateVolumeMultiple >= 1:
+		return fmt.Errorf("volume multiples must be between 0 and 1")
+	case p.ModerateSpreadMultiple > p.HighSpreadMultiple || p.ModerateVolumeMultiple < p.HighVolumeMultiple:
+		return fmt.Errorf("moderate thresholds must be looser than high ones")
+	case p.MinSpread < 0:
+		return fmt.Errorf("min_spread can't be negative")
+	}
+	return nil
+}
+
+// Helper function to name an unnamed policy after where it was selected from
+func named(p models.PolicyOverride, name string) models.PolicyOverride {
+	if p.Name == nil {
+		p.Name = &name
+	}
+	return p
+}
@@ -0,0 +1,96 @@
+package riskassessment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+func TestMergePolicyKeepsExplicitZeros(t *testing.T) {
+	zero := 0.0
+	policy := MergePolicy(DefaultPolicy(), models.PolicyOverride{MinSpread: &zero})
+	if policy.MinSpread != 0 {
+		t.Errorf("min spread %v, want the explicit 0", policy.MinSpread)
+	}
+	if err := ValidatePolicy(policy); err != nil {
+		t.Errorf("a zero min spread failed validation: %v", err)
+	}
+
+	// Unset fields leave the base alone
+	want := DefaultPolicy()
+	want.MinSpread = 0
+	if policy != want {
+		t.Errorf("merged policy %+v, want %+v", policy, want)
+	}
+	if got := MergePolicy(DefaultPolicy(), models.PolicyOverride{}); got != DefaultPolicy() {
+		t.Errorf("empty override changed the policy to %+v", got)
+	}
+}
+
+func TestValidatePolicyRejectsZeroMultiples(t *testing.T) {
+	zero := 0.0
+	policy := MergePolicy(DefaultPolicy(), models.PolicyOverride{HighVolumeMultiple: &zero})
+	if err := ValidatePolicy(policy); err == nil {
+		t.Error("accepted a zero high volume multiple")
+	}
+	loose := DefaultPolicy()
+	loose.ModerateSpreadMultiple = loose.HighSpreadMultiple + 1
+	if err := ValidatePolicy(loose); err == nil {
+		t.Error("accepted a moderate spread multiple above the high one")
+	}
+}
+
+func TestLoadPoliciesLayersPrefixAndAsset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	file := `
+default:
+  window_size: 10
+prefixes:
+  Crypto_:
+    high_spread_multiple: 4
+    min_spread: 0
+assets:
+  Crypto_BTC:
+    name: btc
+    window_size: 20
+`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := LoadPolicies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	btc := set.For("Crypto_BTC")
+	if btc.Name != "btc" || btc.WindowSize != 20 || btc.HighSpreadMultiple != 4 || btc.MinSpread != 0 {
+		t.Errorf("Crypto_BTC policy %+v, want the asset over the prefix over the default", btc)
+	}
+	eth := set.For("Crypto_ETH")
+	if eth.Name != "prefix Crypto_" || eth.WindowSize != 10 || eth.MinSpread != 0 {
+		t.Errorf("Crypto_ETH policy %+v, want the prefix policy with the file default window", eth)
+	}
+	etf := set.For("ETF_SPY")
+	if etf.WindowSize != 10 || etf.MinSpread != DefaultPolicy().MinSpread {
+		t.Errorf("ETF_SPY policy %+v, want the file default", etf)
+	}
+}
+
+func TestAssessLiquidityAppliesZeroMinSpread(t *testing.T) {
+	records := testRecords(60, 9)
+	// A volume collapse on tight spreads is only high risk once the spread floor is gone
+	for i := range records {
+		records[i].BidAskSpread = records[i].BidPrice * 0.0001
+	}
+	records[59].Volume = 1
+
+	floored := AssessLiquidity(records, nil, Options{})
+	zero := 0.0
+	unfloored := AssessLiquidity(records, nil, Options{Policy: MergePolicy(DefaultPolicy(), models.PolicyOverride{MinSpread: &zero})})
+	if floored.CurrentHighRiskCount != 0 || unfloored.CurrentHighRiskCount != 1 {
+		t.Errorf("high risk counts %d with the default floor and %d without, want 0 and 1",
+			floored.CurrentHighRiskCount, unfloored.CurrentHighRiskCount)
+	}
+}
@@ -0,0 +1,111 @@
+package riskassessment
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Liquidity regimes, ordered from calmest to most stressed
+const (
+	RegimeUnknown  = "unknown"
+	RegimeNormal   = "normal"
+	RegimeThin     = "thin"
+	RegimeStressed = "stressed"
+)
+
+const maxHMMIterations = 100
+
+// AssessRegimes fits a three-state Gaussian HMM to spread percentage, log volume and
+// absolute bid price returns, then names the states by how stressed they look:
+// wide spreads, low volume and large moves all push a state towards "stressed".
+// The per-record sequence is only included when withSequence is set.
+func AssessRegimes(records []models.Record, withSequence bool) models.RegimeReport {
+	report := models.RegimeReport{CurrentRegime: RegimeUnknown}
+
+	// Returns need a previous record, so the first one is skipped
+	var observations [][]float64
+	var observed []models.Record
+	for i := 1; i < len(records); i++ {
+		prev, curr := records[i-1], records[i]
+		if curr.BidPrice <= 0 || prev.BidPrice <= 0 {
+			continue
+		}
+		observations = append(observations, []float64{
+			curr.BidAskSpread / curr.BidPrice,
+			math.Log1p(curr.Volume),
+			math.Abs(math.Log(curr.BidPrice / prev.BidPrice)),
+		})
+		observed = append(observed, curr)
+	}
+
+	names := []string{RegimeNormal, RegimeThin, RegimeStressed}
+	model, err := stats.FitGaussianHMM(observations, len(names), maxHMMIterations)
+	if err != nil {
+		return report
+	}
+
+	// Rank states by a stress score on the standardised means
+	scores := make([]float64, len(names))
+	for f := range observations[0] {
+		column := make([]float64, len(model.Means))
+		for s := range model.Means {
+			column[s] = model.Means[s][f]
+		}
+		spread := 0.0
+		for _, v := range column {
+			spread = math.Max(spread, math.Abs(v-column[0]))
+		}
+		if spread == 0 {
+			continue
+		}
+		for s, v := range column {
+			if f == 1 {
+				scores[s] -= v / spread // lower volume is more stressed
+			} else {
+				scores[s] += v / spread
+			}
+		}
+	}
+	order := []int{0, 1, 2}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] < scores[order[b]] })
+	label := make([]string, len(names))
+	for rank, state := range order {
+		label[state] = names[rank]
+	}
+
+	report.Transitions = map[string]map[string]float64{}
+	report.Profiles = map[string]models.RegimeProfile{}
+	for i, from := range label {
+		report.Transitions[from] = map[string]float64{}
+		for j, to := range label {
+			report.Transitions[from][to] = model.Transition[i][j]
+		}
+		report.Profiles[from] = models.RegimeProfile{
+			SpreadPercentage: model.Means[i][0],
+			LogVolume:        model.Means[i][1],
+			AbsReturn:        model.Means[i][2],
+		}
+	}
+
+	posteriors := model.Posteriors(observations)
+	current := posteriors[len(posteriors)-1]
+	report.CurrentProbabilities = map[string]float64{}
+	for s, p := range current {
+		report.CurrentProbabilities[label[s]] = p
+	}
+
+	sequence := model.Viterbi(observations)
+	report.CurrentRegime = label[sequence[len(sequence)-1]]
+	if withSequence {
+		for i, state := range sequence {
+			report.Sequence = append(report.Sequence, models.RegimePoint{
+				Timestamp: observed[i].Timestamp,
+				Regime:    label[state],
+			})
+		}
+	}
+	return report
+}
@@ -0,0 +1,29 @@
+package riskassessment
+
+import "testing"
+
+func TestAssessRegimesFlagsStressedTail(t *testing.T) {
+	records := testRecords(200, 2)
+	// The last 40 records quote ten times the spread on a fifth of the volume
+	for i := 160; i < len(records); i++ {
+		records[i].BidAskSpread *= 10
+		records[i].Volume /= 5
+	}
+
+	report := AssessRegimes(records, true)
+	if report.CurrentRegime != RegimeStressed {
+		t.Errorf("current regime %q, want %q", report.CurrentRegime, RegimeStressed)
+	}
+	if len(report.Sequence) != len(records)-1 {
+		t.Fatalf("got %d sequence points, want one per record after the first", len(report.Sequence))
+	}
+	if report.Sequence[50].Regime == RegimeStressed {
+		t.Error("a calm record was labelled stressed")
+	}
+}
+
+func TestAssessRegimesWithoutEnoughData(t *testing.T) {
+	if report := AssessRegimes(testRecords(10, 3), false); report.CurrentRegime != RegimeUnknown {
+		t.Errorf("current regime %q on 10 records, want %q", report.CurrentRegime, RegimeUnknown)
+	}
+}
@@ -2,31 +2,58 @@ package riskassessment
 
 import (
 	"fmt"
+	"math"
 
+	"github.com/bedminer1/liquidity_tracker/internal/anomaly"
 	"github.com/bedminer1/liquidity_tracker/internal/models"
 )
 
-func AssessLiquidity(currentRecords, predictions []models.Record, windowSize int) models.LiquidityReport {
+// Severities of a RiskEvent
+const (
+	SeverityHigh         = "high"
+	SeverityModerate     = "moderate"
+	SeverityPossibleHigh = "possible_high"
+)
+
+// Rules a RiskEvent can be triggered by
+const (
+	RuleSpreadBlowout  = "spread_blowout"  // spread above the high spread multiple of its average
+	RuleVolumeCollapse = "volume_collapse" // volume below the high volume multiple of its average
+	RuleSpreadWidening = "spread_widening" // spread above the moderate spread multiple
+	RuleVolumeDrop     = "volume_drop"     // volume below the moderate volume multiple
+)
+
+type Options struct {
+	Policy     models.RiskPolicy // thresholds of the risk rules, the zero value uses DefaultPolicy
+	BoundLevel float64           // confidence level whose prediction bounds are also checked, 0 disables
+
+	Metrics []models.LiquidityMetrics // optional rolling liquidity metrics, the latest window is reported and checked against the rest
+	LVaR    *LVaRConfig               // confidence and horizon of the liquidity-adjusted VaR, nil skips it and zero values use the defaults
+
+	Detectors []string // anomaly detectors to run over history and predictions, see anomaly.Available
+
+	// Models fitted over history on every call, left out unless the caller reports them
+	// so the threshold assessment stays cheap
+	Volatility   bool // GARCH(1,1) volatility of price and spread
+	Regimes      bool // Gaussian HMM liquidity regimes
+	ChangePoints bool // PELT breaks in spread and volume
+	Score        bool // composite 0-100 risk score
+
+	// Measure LVaR and the score over history and predictions together rather than history
+	// alone, so a scenario applied to the forecast shows up in them
+	MeasurePredictions bool
+}
+
+func AssessLiquidity(currentRecords, predictions []models.Record, opts Options) models.LiquidityReport {
+	policy := policyOrDefault(opts.Policy)
 	var report models.LiquidityReport
 	if len(currentRecords) > 0 { report.AssetType = currentRecords[0].AssetType }
 
 	allRecords := append(currentRecords, predictions...)
 
 	// Sliding window for moving averages
-	var volumeWindow []float64
-	var spreadWindow []float64
-
-	// Helper to calculate moving average
-	movingAverage := func(data []float64) float64 {
-		if len(data) == 0 {
-			return 0
-		}
-		sum := 0.0
-		for _, v := range data {
-			sum += v
-		}
-		return sum / float64(len(data))
-	}
+	volumeWindow := movingWindow{size: policy.WindowSize}
+	spreadWindow := movingWindow{size: policy.WindowSize}
 
 	// Counters for risk levels
 	currentHighRiskCount := 0
@@ -34,31 +61,44 @@ func AssessLiquidity(currentRecords, predictions []models.Record, windowSize int
 	predictedHighRiskCount := 0
 	predictedModerateRiskCount := 0
 
+	predictedPossibleHighRiskCount := 0
+
 	var currentWarnings []string
 	var predictedWarnings []string
+	var possibleHighRiskWarnings []string
+	var events []models.RiskEvent
 
 	for idx, record := range allRecords {
 		isPrediction := idx >= len(currentRecords)
+		if record.BidPrice <= 0 {
+			continue // no spread percentage without a price
+		}
 
 		// Calculate severity
 		spreadPercentage := record.BidAskSpread / record.BidPrice
-		volumeWindow = append(volumeWindow, record.Volume)
-		spreadWindow = append(spreadWindow, spreadPercentage)
-
-		if len(volumeWindow) > windowSize {
-			volumeWindow = volumeWindow[1:]
-		}
-		if len(spreadWindow) > windowSize {
-			spreadWindow = spreadWindow[1:]
-		}
+		volumeMA := volumeWindow.push(record.Volume)
+		spreadMA := spreadWindow.push(spreadPercentage)
 
-		volumeMA := movingAverage(volumeWindow)
-		spreadMA := movingAverage(spreadWindow)
+		isHighRisk := highRisk(policy, spreadPercentage, spreadMA, record.Volume, volumeMA)
+		isModerateRisk := moderateRisk(policy, spreadPercentage, spreadMA, record.Volume, volumeMA)
 
-		isHighRisk := (spreadPercentage > 3.0*spreadMA || record.Volume < 0.4*volumeMA) && spreadPercentage > 0.0002
-		isModerateRisk := spreadPercentage > 1.2*spreadMA || record.Volume < 0.7*volumeMA
+		// Check the pessimistic end of the prediction interval: wider spread, thinner volume
+		if isPrediction && !isHighRisk && opts.BoundLevel > 0 {
+			if interval, ok := intervalAt(record, opts.BoundLevel); ok {
+				upperSpreadPercentage := interval.BidAskSpreadUpper / record.BidPrice
+				if highRisk(policy, upperSpreadPercentage, spreadMA, interval.VolumeLower, volumeMA) {
+					predictedPossibleHighRiskCount++
+					event := newEvent(record, SeverityPossibleHigh, highRules(policy, upperSpreadPercentage, spreadMA, interval.VolumeLower, volumeMA), upperSpreadPercentage, spreadMA, interval.VolumeLower, volumeMA, true)
+					event.BoundLevel = opts.BoundLevel
+					events = append(events, event)
+					possibleHighRiskWarnings = append(possibleHighRiskWarnings, fmt.Sprintf("Possible high risk for %s at %s (%.0f%% bound): Spread up to %.2f%% (MA=%.2f%%), Volume down to %.0f (MA=%.0f)",
+						record.AssetType, record.Timestamp, opts.BoundLevel*100, upperSpreadPercentage*100, spreadMA*100, interval.VolumeLower, volumeMA))
+				}
+			}
+		}
 
 		if isHighRisk {
+			events = append(events, newEvent(record, SeverityHigh, highRules(policy, spreadPercentage, spreadMA, record.Volume, volumeMA), spreadPercentage, spreadMA, record.Volume, volumeMA, isPrediction))
 			if isPrediction {
 				predictedHighRiskCount++
 				predictedWarnings = append(predictedWarnings, fmt.Sprintf("Predicted high risk for %s at %s: Spread=%.2f%% (MA=%.2f%%), Volume=%.0f (MA=%.0f)",
@@ -69,6 +109,7 @@ func AssessLiquidity(currentRecords, predictions []models.Record, windowSize int
 					record.AssetType, record.Timestamp, spreadPercentage*100, spreadMA*100, record.Volume, volumeMA))
 			}
 		} else if isModerateRisk {
+			events = append(events, newEvent(record, SeverityModerate, moderateRules(policy, spreadPercentage, spreadMA, record.Volume, volumeMA), spreadPercentage, spreadMA, record.Volume, volumeMA, isPrediction))
 			if isPrediction {
 				predictedModerateRiskCount++
 			} else {
@@ -88,8 +129,172 @@ func AssessLiquidity(currentRecords, predictions []models.Record, windowSize int
 	report.PredictedWarnings = predictedWarnings
 	report.CurrentHighRiskCount = currentHighRiskCount
 	report.PredictedHighRiskCount = predictedHighRiskCount
+	report.Events = events
 	report.CurrentModerateRiskCount = currentModerateRiskCount
 	report.PredictedModerateRiskCount = predictedModerateRiskCount
 
+	report.Policy = policy
+	report.BoundLevel = opts.BoundLevel
+	report.PredictedPossibleHighRiskCount = predictedPossibleHighRiskCount
+	report.PossibleHighRiskWarnings = possibleHighRiskWarnings
+
+	if opts.Volatility {
+		report.Volatility = AssessVolatility(currentRecords, len(predictions))
+	}
+	if opts.Regimes {
+		report.Regime = AssessRegimes(currentRecords, false)
+	}
+	if opts.ChangePoints {
+		report.ChangePoints, _ = DetectChangePoints(currentRecords, ChangePointPELT)
+	}
+
+	measured := currentRecords
+	if opts.MeasurePredictions {
+		measured = allRecords
+	}
+	if opts.LVaR != nil {
+		report.LVaR, _ = ComputeLVaR(measured, *opts.LVaR)
+	}
+	if opts.Score {
+		report.Score = ScoreLiquidity(measured, predictedHighRiskCount, predictedModerateRiskCount, len(predictions), policy.WindowSize)
+	}
+
+	if len(opts.Detectors) > 0 {
+		// Unknown detector names are rejected by the caller, an error here leaves the section empty
+		report.Anomalies, _ = anomaly.Run(allRecords, opts.Detectors)
+		for i := range report.Anomalies.Anomalies {
+			report.Anomalies.Anomalies[i].IsPrediction = report.Anomalies.Anomalies[i].Index >= len(currentRecords)
+		}
+	}
+
+	if len(opts.Metrics) > 0 {
+		report.Metrics = opts.Metrics[len(opts.Metrics)-1]
+		report.MetricWarnings = metricWarnings(report.AssetType, opts.Metrics)
+	}
+
 	return report
 }
+
+// PredictedHighRisk applies the high risk rule to each prediction, with the moving
+// averages seeded from the tail of history. Cheaper than AssessLiquidity when only
+// the flags are needed, e.g. across many simulated paths.
+func PredictedHighRisk(history, predictions []models.Record, policy models.RiskPolicy) []bool {
+	high, _ := PredictedRisk(history, predictions, policy)
+	return high
+}
+
+// PredictedRisk flags each prediction high or moderate risk as AssessLiquidity would,
+// with a prediction flagged high never also flagged moderate
+func PredictedRisk(history, predictions []models.Record, policy models.RiskPolicy) (high, moderate []bool) {
+	policy = policyOrDefault(policy)
+	volumeWindow := movingWindow{size: policy.WindowSize}
+	spreadWindow := movingWindow{size: policy.WindowSize}
+	for _, record := range history[max(len(history)-policy.WindowSize, 0):] {
+		if record.BidPrice <= 0 {
+			continue
+		}
+		volumeWindow.push(record.Volume)
+		spreadWindow.push(record.BidAskSpread / record.BidPrice)
+	}
+
+	high = make([]bool, len(predictions))
+	moderate = make([]bool, len(predictions))
+	for i, record := range predictions {
+		if record.BidPrice <= 0 {
+			continue
+		}
+		spreadPercentage := record.BidAskSpread / record.BidPrice
+		volumeMA := volumeWindow.push(record.Volume)
+		spreadMA := spreadWindow.push(spreadPercentage)
+		high[i] = highRisk(policy, spreadPercentage, spreadMA, record.Volume, volumeMA)
+		moderate[i] = !high[i] && moderateRisk(policy, spreadPercentage, spreadMA, record.Volume, volumeMA)
+	}
+	return high, moderate
+}
+
+// Helper function for the policy to apply, DefaultPolicy when none was given. A policy
+// that was given is used as is, so a threshold set to zero stays zero.
+func policyOrDefault(policy models.RiskPolicy) models.RiskPolicy {
+	if policy == (models.RiskPolicy{}) {
+		return DefaultPolicy()
+	}
+	return policy
+}
+
+// movingWindow keeps the most recent size values
+type movingWindow struct {
+	size   int
+	values []float64
+}
+
+// push adds v and returns the average of the window including it
+func (w *movingWindow) push(v float64) float64 {
+	w.values = append(w.values, v)
+	if len(w.values) > w.size {
+		w.values = w.values[1:]
+	}
+	sum := 0.0
+	for _, value := range w.values {
+		sum += value
+	}
+	return sum / float64(len(w.values))
+}
+
+// Helper function for the high risk rule: a spread blowout or volume collapse relative
+// to the moving averages, ignoring spreads too tight to matter
+func highRisk(policy models.RiskPolicy, spreadPercentage, spreadMA, volume, volumeMA float64) bool {
+	return (spreadPercentage > policy.HighSpreadMultiple*spreadMA || volume < policy.HighVolumeMultiple*volumeMA) && spreadPercentage > policy.MinSpread
+}
+
+// Helper function for the moderate risk rule, a milder move in either direction
+func moderateRisk(policy models.RiskPolicy, spreadPercentage, spreadMA, volume, volumeMA float64) bool {
+	return spreadPercentage > policy.ModerateSpreadMultiple*spreadMA || volume < policy.ModerateVolumeMultiple*volumeMA
+}
+
+// Helper function to find the prediction interval at the given confidence level
+func intervalAt(record models.Record, level float64) (models.PredictionInterval, bool) {
+	for _, interval := range record.Intervals {
+		if math.Abs(interval.Level-level) < 1e-9 {
+			return interval, true
+		}
+	}
+	return models.PredictionInterval{}, false
+}
+
+// Helper function for the rules behind a high risk flag
+func highRules(policy models.RiskPolicy, spreadPercentage, spreadMA, volume, volumeMA float64) []string {
+	var rules []string
+	if spreadPercentage > policy.HighSpreadMultiple*spreadMA {
+		rules = append(rules, RuleSpreadBlowout)
+	}
+	if volume < policy.HighVolumeMultiple*volumeMA {
+		rules = append(rules, RuleVolumeCollapse)
+	}
+	return rules
+}
+
+// Helper function for the rules behind a moderate risk flag
+func moderateRules(policy models.RiskPolicy, spreadPercentage, spreadMA, volume, volumeMA float64) []string {
+	var rules []string
+	if spreadPercentage > policy.ModerateSpreadMultiple*spreadMA {
+		rules = append(rules, RuleSpreadWidening)
+	}
+	if volume < policy.ModerateVolumeMultiple*volumeMA {
+		rules = append(rules, RuleVolumeDrop)
+	}
+	return rules
+}
+
+func newEvent(record models.Record, severity string, rules []string, spreadPercentage, spreadMA, volume, volumeMA float64, isPrediction bool) models.RiskEvent {
+	return models.RiskEvent{
+		Timestamp:        record.Timestamp,
+		AssetType:        record.AssetType,
+		Severity:         severity,
+		Rules:            rules,
+		SpreadPercentage: spreadPercentage,
+		SpreadMA:         spreadMA,
+		Volume:           volume,
+		VolumeMA:         volumeMA,
+		IsPrediction:     isPrediction,
+	}
+}
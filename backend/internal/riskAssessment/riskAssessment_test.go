@@ -0,0 +1,87 @@
+package riskassessment
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Helper function for n daily records of a random walk price with noisy spread and volume
+func testRecords(n int, seed uint64) []models.Record {
+	rng := rand.New(rand.NewPCG(seed, 1))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]models.Record, n)
+	price := 100.0
+	for i := range records {
+		price *= math.Exp(0.01 * rng.NormFloat64())
+		records[i] = models.Record{
+			AssetType:    "TEST",
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			BidAskSpread: price * (0.002 + 0.0002*rng.NormFloat64()),
+			Volume:       1000 * math.Exp(0.1*rng.NormFloat64()),
+			BidPrice:     price,
+		}
+	}
+	return records
+}
+
+func TestAssessLiquidityFitsOnlyRequestedModels(t *testing.T) {
+	records := testRecords(200, 1)
+	history, predictions := records[:180], records[180:]
+
+	cheap := AssessLiquidity(history, predictions, Options{})
+	if cheap.Volatility.Regime != "" || cheap.Regime.CurrentRegime != "" || cheap.Score.Level != "" || cheap.LVaR.Confidence != 0 {
+		t.Errorf("assessment without options fitted models: volatility %q, regime %q, score %q, lvar %v",
+			cheap.Volatility.Regime, cheap.Regime.CurrentRegime, cheap.Score.Level, cheap.LVaR.Confidence)
+	}
+
+	full := AssessLiquidity(history, predictions, Options{Volatility: true, Regimes: true, ChangePoints: true, Score: true, LVaR: &LVaRConfig{}})
+	if full.Volatility.Regime == "" || full.Regime.CurrentRegime == "" || full.Score.Level == "" || full.LVaR.Confidence == 0 {
+		t.Errorf("requested models missing: volatility %q, regime %q, score %q, lvar %v",
+			full.Volatility.Regime, full.Regime.CurrentRegime, full.Score.Level, full.LVaR.Confidence)
+	}
+	if full.HighRiskCount != cheap.HighRiskCount || full.ModerateRiskCount != cheap.ModerateRiskCount {
+		t.Error("the optional models changed the risk event counts")
+	}
+}
+
+func TestAssessLiquidityRecordsEvents(t *testing.T) {
+	records := testRecords(60, 10)
+	for i := range records {
+		records[i].BidAskSpread = records[i].BidPrice * 0.002
+		records[i].Volume = 1000
+	}
+	records[30].BidAskSpread *= 5 // blowout
+	records[45].Volume = 600      // milder drop, moderate only
+	history, predictions := records[:50], records[50:]
+	predictions[5].Volume = 100 // predicted collapse
+
+	report := AssessLiquidity(history, predictions, Options{})
+	if len(report.Events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(report.Events), report.Events)
+	}
+	checks := []struct {
+		at           int
+		severity     string
+		rule         string
+		isPrediction bool
+	}{
+		{30, SeverityHigh, RuleSpreadBlowout, false},
+		{45, SeverityModerate, RuleVolumeDrop, false},
+		{55, SeverityHigh, RuleVolumeCollapse, true},
+	}
+	for i, check := range checks {
+		event := report.Events[i]
+		if !event.Timestamp.Equal(records[check.at].Timestamp) || event.Severity != check.severity ||
+			len(event.Rules) != 1 || event.Rules[0] != check.rule || event.IsPrediction != check.isPrediction {
+			t.Errorf("event %d = %+v, want %s %s at record %d", i, event, check.severity, check.rule, check.at)
+		}
+	}
+	if report.CurrentHighRiskCount != 1 || report.CurrentModerateRiskCount != 1 || report.PredictedHighRiskCount != 1 {
+		t.Errorf("counts %d/%d current high/moderate and %d predicted high, want 1, 1 and 1",
+			report.CurrentHighRiskCount, report.CurrentModerateRiskCount, report.PredictedHighRiskCount)
+	}
+}
@@ -0,0 +1,138 @@
+package riskassessment
+
+import (
+	"math"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Score components, in the order they are reported
+const (
+	ComponentSpreadLevel      = "spread_level"      // mean spread percentage over the recent window
+	ComponentSpreadVolatility = "spread_volatility" // coefficient of variation of spread percentage
+	ComponentVolumeDepth      = "volume_depth"      // recent volume over median volume
+	ComponentVolumeTrend      = "volume_trend"      // log change in volume across history, from a least squares fit
+	ComponentPredictedEvents  = "predicted_events"  // share of predictions flagged, moderate counting half
+)
+
+// Weights of each component, summing to 1
+var scoreWeights = map[string]float64{
+	ComponentSpreadLevel:      0.25,
+	ComponentSpreadVolatility: 0.15,
+	ComponentVolumeDepth:      0.20,
+	ComponentVolumeTrend:      0.15,
+	ComponentPredictedEvents:  0.25,
+}
+
+// Reference levels the components saturate against
+const (
+	referenceSpread = 0.005 // a 0.5% spread scores 63% spread level risk
+	scoreLevelLow   = 25
+	scoreLevelMid   = 50
+	scoreLevelHigh  = 75
+)
+
+// ScoreLiquidity turns history and the assessment's predicted event counts into a 0-100
+// risk score. Every component is scale free (a ratio, a percentage or a share) so the
+// score of a thinly traded ETF is comparable with that of a crypto pair. recentWindow is
+// how many of the latest records count as recent.
+func ScoreLiquidity(history []models.Record, predictedHigh, predictedModerate, predictions, recentWindow int) models.RiskScore {
+	var spreads, volumes []float64
+	for _, record := range history {
+		if record.BidPrice > 0 {
+			spreads = append(spreads, record.BidAskSpread/record.BidPrice)
+		}
+		volumes = append(volumes, record.Volume)
+	}
+	recentWindow = max(recentWindow, 1)
+
+	var components []models.ScoreComponent
+	add := func(name string, value, risk float64) {
+		risk = math.Max(0, math.Min(1, risk))
+		if math.IsNaN(risk) {
+			risk = 0
+		}
+		components = append(components, models.ScoreComponent{
+			Name:         name,
+			Value:        value,
+			Risk:         risk,
+			Weight:       scoreWeights[name],
+			Contribution: risk * scoreWeights[name] * 100,
+		})
+	}
+
+	// Wider spreads cost more to trade, saturating so one illiquid asset can't dominate
+	recentSpread := stats.Mean(tail(spreads, recentWindow))
+	add(ComponentSpreadLevel, recentSpread, 1-math.Exp(-recentSpread/referenceSpread))
+
+	// Erratic spreads mean the cost of exit is hard to plan for
+	cv := 0.0
+	if m := stats.Mean(spreads); m > 0 {
+		cv = stats.StdDev(spreads) / m
+	}
+	add(ComponentSpreadVolatility, cv, 1-math.Exp(-cv))
+
+	// Volume well below its usual level means thin markets right now
+	depth := 1.0
+	if median := stats.Quantile(volumes, 0.5); median > 0 {
+		depth = stats.Mean(tail(volumes, recentWindow)) / median
+	}
+	add(ComponentVolumeDepth, depth, 1-depth)
+
+	// A steady decline in volume, halving across history scores full risk
+	trend := logVolumeTrend(volumes)
+	add(ComponentVolumeTrend, trend, -trend/math.Ln2)
+
+	// Predicted events as a share of predictions so longer forecasts don't score higher
+	share := 0.0
+	if predictions > 0 {
+		share = (float64(predictedHigh) + 0.5*float64(predictedModerate)) / float64(predictions)
+	}
+	add(ComponentPredictedEvents, share, share)
+
+	score := models.RiskScore{Components: components}
+	for _, component := range components {
+		score.Score += component.Contribution
+	}
+	switch {
+	case score.Score >= scoreLevelHigh:
+		score.Level = "high"
+	case score.Score >= scoreLevelMid:
+		score.Level = "elevated"
+	case score.Score >= scoreLevelLow:
+		score.Level = "moderate"
+	default:
+		score.Level = "low"
+	}
+	return score
+}
+
+// Helper function for the total change in log volume implied by a least squares line
+// through it, from the first record to the last
+func logVolumeTrend(volumes []float64) float64 {
+	n := len(volumes)
+	if n < 2 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i, v := range volumes {
+		x, y := float64(i), math.Log1p(math.Max(v, 0))
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	fn := float64(n)
+	denominator := fn*sxx - sx*sx
+	if denominator == 0 {
+		return 0
+	}
+	slope := (fn*sxy - sx*sy) / denominator
+	return slope * float64(n-1)
+}
+
+// Helper function for the last n values
+func tail(values []float64, n int) []float64 {
+	return values[max(len(values)-n, 0):]
+}
@@ -0,0 +1,90 @@
+package riskassessment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+func TestScoreLiquidityStaysInRange(t *testing.T) {
+	calm := testRecords(120, 11)
+	stressed := testRecords(120, 11)
+	for i := range stressed {
+		stressed[i].BidAskSpread *= 20 * (1 + float64(i%7))
+		stressed[i].Volume *= math.Pow(0.95, float64(i))
+	}
+	zeroPrices := testRecords(20, 12)
+	for i := range zeroPrices {
+		zeroPrices[i].BidPrice = 0
+	}
+
+	cases := []struct {
+		name                        string
+		history                     []models.Record
+		high, moderate, predictions int
+	}{
+		{"calm", calm, 0, 0, 14},
+		{"stressed", stressed, 14, 0, 14},
+		{"no history", nil, 0, 0, 0},
+		{"zero prices", zeroPrices, 0, 0, 0},
+	}
+	scores := map[string]float64{}
+	for _, c := range cases {
+		score := ScoreLiquidity(c.history, c.high, c.moderate, c.predictions, 8)
+		if math.IsNaN(score.Score) || score.Score < 0 || score.Score > 100 {
+			t.Errorf("%s: score %v outside 0-100", c.name, score.Score)
+		}
+		total, weights := 0.0, 0.0
+		for _, component := range score.Components {
+			if component.Risk < 0 || component.Risk > 1 {
+				t.Errorf("%s: %s risk %v outside 0-1", c.name, component.Name, component.Risk)
+			}
+			total += component.Contribution
+			weights += component.Weight
+		}
+		if math.Abs(total-score.Score) > 1e-9 || math.Abs(weights-1) > 1e-9 {
+			t.Errorf("%s: contributions add to %v of %v with weights summing to %v", c.name, total, score.Score, weights)
+		}
+		scores[c.name] = score.Score
+	}
+	if scores["stressed"] <= scores["calm"]+25 {
+		t.Errorf("stressed score %.1f barely above calm %.1f", scores["stressed"], scores["calm"])
+	}
+}
+
+func TestScoreLevels(t *testing.T) {
+	calm := ScoreLiquidity(testRecords(120, 13), 0, 0, 14, 8)
+	if calm.Level != "low" {
+		t.Errorf("calm asset scored %.1f %s, want low", calm.Score, calm.Level)
+	}
+	// Every prediction flagged high adds the full 25 points of that component
+	flagged := ScoreLiquidity(testRecords(120, 13), 14, 0, 14, 8)
+	if math.Abs(flagged.Score-calm.Score-25) > 1e-9 {
+		t.Errorf("flagging every prediction moved the score from %.2f to %.2f, want +25", calm.Score, flagged.Score)
+	}
+}
+
+func TestScoreMatchesAssessment(t *testing.T) {
+	records := testRecords(120, 14)
+	history, predictions := records[:100], records[100:]
+	predictions[3].Volume /= 10
+
+	report := AssessLiquidity(history, predictions, Options{Score: true})
+	high, moderate := PredictedRisk(history, predictions, models.RiskPolicy{})
+	score := ScoreLiquidity(history, countTrue(high), countTrue(moderate), len(predictions), DefaultPolicy().WindowSize)
+	if score.Score != report.Score.Score {
+		t.Errorf("scoring from the predicted flags gave %v, the assessment %v", score.Score, report.Score.Score)
+	}
+}
+
+// Helper function to count the set flags
+func countTrue(flags []bool) int {
+	n := 0
+	for _, flag := range flags {
+		if flag {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,103 @@
+package riskassessment
+
+import (
+	"math"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Volatility regimes, ordered from calmest to most stressed
+const (
+	VolatilityUnknown  = "unknown"
+	VolatilityLow      = "low"
+	VolatilityNormal   = "normal"
+	VolatilityElevated = "elevated"
+	VolatilityHigh     = "high"
+)
+
+// Current-to-long-run volatility ratios separating the regimes
+const (
+	lowVolatilityRatio      = 0.8
+	elevatedVolatilityRatio = 1.2
+	highVolatilityRatio     = 1.5
+)
+
+// AssessVolatility fits GARCH(1,1) to bid price log returns and to spread changes,
+// forecasts volatility horizon steps ahead and classifies the current regime
+func AssessVolatility(records []models.Record, horizon int) models.VolatilityReport {
+	var bidPrices, spreads []float64
+	for _, record := range records {
+		bidPrices = append(bidPrices, record.BidPrice)
+		spreads = append(spreads, record.BidAskSpread)
+	}
+
+	report := models.VolatilityReport{
+		BidPrice:     forecastVolatility(stats.LogReturns(bidPrices), horizon),
+		BidAskSpread: forecastVolatility(stats.Changes(spreads), horizon),
+	}
+	report.Regime = report.BidPrice.Regime
+	if regimeRank(report.BidAskSpread.Regime) > regimeRank(report.Regime) {
+		report.Regime = report.BidAskSpread.Regime
+	}
+	return report
+}
+
+// Helper function to fit one series and summarise it
+func forecastVolatility(series []float64, horizon int) models.VolatilityForecast {
+	model, err := stats.FitGARCH(series)
+	if err != nil {
+		return models.VolatilityForecast{Regime: VolatilityUnknown}
+	}
+
+	// The first forecast step is the one-step-ahead conditional variance
+	variances := model.ForecastVariance(max(horizon, 1))
+	forecast := make([]float64, horizon)
+	for i := range forecast {
+		forecast[i] = math.Sqrt(variances[i])
+	}
+	current := math.Sqrt(variances[0])
+	longRun := math.Sqrt(model.LongRunVariance())
+
+	result := models.VolatilityForecast{
+		Omega:              model.Omega,
+		Alpha:              model.Alpha,
+		Beta:               model.Beta,
+		Persistence:        model.Persistence(),
+		CurrentVolatility:  current,
+		LongRunVolatility:  longRun,
+		ForecastVolatility: forecast,
+	}
+	if longRun > 0 {
+		result.VolatilityRatio = current / longRun
+	}
+
+	switch ratio := result.VolatilityRatio; {
+	case longRun == 0:
+		result.Regime = VolatilityUnknown
+	case ratio >= highVolatilityRatio:
+		result.Regime = VolatilityHigh
+	case ratio >= elevatedVolatilityRatio:
+		result.Regime = VolatilityElevated
+	case ratio <= lowVolatilityRatio:
+		result.Regime = VolatilityLow
+	default:
+		result.Regime = VolatilityNormal
+	}
+	return result
+}
+
+// Helper function to order regimes so the worst can be picked
+func regimeRank(regime string) int {
+	switch regime {
+	case VolatilityLow:
+		return 1
+	case VolatilityNormal:
+		return 2
+	case VolatilityElevated:
+		return 3
+	case VolatilityHigh:
+		return 4
+	}
+	return 0
+}
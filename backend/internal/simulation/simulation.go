@@ -0,0 +1,203 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	riskassessment "github.com/bedminer1/liquidity_tracker/internal/riskAssessment"
+)
+
+type Config struct {
+	Paths   int
+	Steps   int
+	Step    time.Duration
+	Seed    uint64 // the same seed always produces the same summary, whatever the worker count
+	Workers int
+	Policy  models.RiskPolicy // thresholds of the high risk rule
+}
+
+// Distribution summarises one field across every simulated path at a single step
+type Distribution struct {
+	Mean float64 `json:"mean"`
+	P5   float64 `json:"p5"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P95  float64 `json:"p95"`
+}
+
+type StepSummary struct {
+	Step                int          `json:"step"`
+	Timestamp           time.Time    `json:"timestamp"`
+	BidAskSpread        Distribution `json:"bid_ask_spread"`
+	SpreadPercentage    Distribution `json:"spread_percentage"` // spread over bid price
+	Volume              Distribution `json:"volume"`
+	BidPrice            Distribution `json:"bid_price"`
+	HighRiskProbability float64      `json:"high_risk_probability"` // share of paths where AssessLiquidity's high risk rule fires
+}
+
+type Result struct {
+	Paths int           `json:"paths"`
+	Seed  string        `json:"seed"` // string since seeds above 2^53 don't survive as JSON numbers
+	Steps []StepSummary `json:"steps"`
+	// Share of paths with at least one high risk step anywhere in the horizon
+	AnyHighRiskProbability float64 `json:"any_high_risk_probability"`
+}
+
+// Run samples cfg.Paths stochastic paths from a fitted forecaster across cfg.Workers
+// goroutines and summarises them per step. It stops early with ctx's error when
+// ctx is cancelled. The forecaster's Predict must be safe for concurrent use.
+func Run(ctx context.Context, forecaster forecast.Forecaster, history []models.Record, cfg Config) (Result, error) {
+	if cfg.Paths < 1 || cfg.Steps < 1 {
+		return Result{}, fmt.Errorf("need at least one path and one step")
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+
+	paths := make([][]models.Record, cfg.Paths)
+	highRisk := make([][]bool, cfg.Paths)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	errs := make(chan error, cfg.Workers)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				predictions, err := forecaster.Predict(forecast.Options{
+					Steps:      cfg.Steps,
+					Step:       cfg.Step,
+					Stochastic: true,
+					Seed:       pathSeed(cfg.Seed, i),
+				})
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+				paths[i] = predictions
+				highRisk[i] = riskassessment.PredictedHighRisk(history, predictions, cfg.Policy)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < cfg.Paths; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return Result{}, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return summarise(paths, highRisk, cfg), nil
+}
+
+// Helper function to derive each path's seed from the run seed, so results don't
+// depend on which worker picked the path up
+func pathSeed(seed uint64, path int) uint64 {
+	return rand.New(rand.NewPCG(seed, uint64(path))).Uint64()
+}
+
+// Helper function to collapse the paths into per-step distributions
+func summarise(paths [][]models.Record, highRisk [][]bool, cfg Config) Result {
+	result := Result{Paths: cfg.Paths, Seed: strconv.FormatUint(cfg.Seed, 10)}
+
+	anyHighRisk := 0
+	for _, flags := range highRisk {
+		for _, flagged := range flags {
+			if flagged {
+				anyHighRisk++
+				break
+			}
+		}
+	}
+	result.AnyHighRiskProbability = float64(anyHighRisk) / float64(cfg.Paths)
+
+	column := make([]float64, cfg.Paths)
+	collect := func(step int, selector func(models.Record) float64) Distribution {
+		for i, path := range paths {
+			column[i] = selector(path[step])
+		}
+		return distribution(column)
+	}
+
+	steps := len(paths[0])
+	for step := 0; step < steps; step++ {
+		hits := 0
+		for _, flags := range highRisk {
+			if flags[step] {
+				hits++
+			}
+		}
+		result.Steps = append(result.Steps, StepSummary{
+			Step:         step + 1,
+			Timestamp:    paths[0][step].Timestamp,
+			BidAskSpread: collect(step, func(r models.Record) float64 { return r.BidAskSpread }),
+			SpreadPercentage: collect(step, func(r models.Record) float64 {
+				if r.BidPrice == 0 {
+					return 0
+				}
+				return r.BidAskSpread / r.BidPrice
+			}),
+			Volume:              collect(step, func(r models.Record) float64 { return r.Volume }),
+			BidPrice:            collect(step, func(r models.Record) float64 { return r.BidPrice }),
+			HighRiskProbability: float64(hits) / float64(cfg.Paths),
+		})
+	}
+	return result
+}
+
+// Helper function to summarise a sample, values is reordered in place
+func distribution(values []float64) Distribution {
+	sort.Float64s(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return Distribution{
+		Mean: sum / float64(len(values)),
+		P5:   percentile(values, 5),
+		P25:  percentile(values, 25),
+		P50:  percentile(values, 50),
+		P75:  percentile(values, 75),
+		P95:  percentile(values, 95),
+	}
+}
+
+// Helper function for the linearly interpolated percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := rank - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/forecast"
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// randomWalk forecasts a noisy path from its seed, standing in for a fitted model
+type randomWalk struct {
+	last models.Record
+}
+
+func (w randomWalk) Fit([]models.Record, time.Duration) error { return nil }
+
+func (w randomWalk) Predict(opts forecast.Options) ([]models.Record, error) {
+	rng := rand.New(rand.NewPCG(opts.Seed, 0))
+	record := w.last
+	var predictions []models.Record
+	for i := 0; i < opts.Steps; i++ {
+		record.Timestamp = record.Timestamp.Add(opts.Step)
+		record.BidPrice += rng.NormFloat64()
+		record.BidAskSpread *= 1 + 0.2*rng.NormFloat64()
+		record.Volume *= 1 + 0.2*rng.NormFloat64()
+		predictions = append(predictions, record)
+	}
+	return predictions, nil
+}
+
+func testHistory() []models.Record {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var history []models.Record
+	for i := 0; i < 30; i++ {
+		history = append(history, models.Record{
+			AssetType:    "TEST",
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			BidAskSpread: 0.5,
+			Volume:       1000,
+			BidPrice:     100,
+		})
+	}
+	return history
+}
+
+func TestRunIsReproducibleAcrossWorkers(t *testing.T) {
+	history := testHistory()
+	model := randomWalk{last: history[len(history)-1]}
+	cfg := Config{Paths: 200, Steps: 10, Step: 24 * time.Hour, Seed: 7, Workers: 1}
+
+	single, err := Run(context.Background(), model, history, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Workers = 8
+	parallel, err := Run(context.Background(), model, history, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(single, parallel) {
+		t.Error("the same seed gave different summaries with 1 and 8 workers")
+	}
+
+	if len(single.Steps) != cfg.Steps {
+		t.Fatalf("got %d step summaries, want %d", len(single.Steps), cfg.Steps)
+	}
+	for _, step := range single.Steps {
+		d := step.BidPrice
+		if !(d.P5 <= d.P25 && d.P25 <= d.P50 && d.P50 <= d.P75 && d.P75 <= d.P95) {
+			t.Fatalf("step %d percentiles out of order: %+v", step.Step, d)
+		}
+		if step.HighRiskProbability < 0 || step.HighRiskProbability > single.AnyHighRiskProbability {
+			t.Fatalf("step %d high risk probability %.3f outside [0, %.3f]", step.Step, step.HighRiskProbability, single.AnyHighRiskProbability)
+		}
+	}
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	history := testHistory()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Run(ctx, randomWalk{last: history[len(history)-1]}, history, Config{Paths: 1000, Steps: 10, Step: 24 * time.Hour, Workers: 4})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run on a cancelled context returned %v, want context.Canceled", err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	for _, test := range []struct{ p, want float64 }{{0, 1}, {25, 2}, {50, 3}, {90, 4.6}, {100, 5}} {
+		if got := percentile(sorted, test.p); got != test.want {
+			t.Errorf("percentile(%v) = %v, want %v", test.p, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Search limits for automatic order selection
+const (
+	maxARIMAOrder         = 2  // p and q
+	maxSeasonalARIMAOrder = 1  // P and Q
+	maxARIMASeasonLength  = 60 // longer seasons make the seasonal lags too sparse to estimate
+	minSeasonalACF        = 0.3
+	unitRootACF           = 0.9 // lag-1 autocorrelation above which a series is differenced
+)
+
+// ARIMAOrder describes a SARIMA(p,d,q)(P,D,Q)m model. SeasonLength 0 means non-seasonal.
+type ARIMAOrder struct {
+	P            int `json:"p"`
+	D            int `json:"d"`
+	Q            int `json:"q"`
+	SeasonalP    int `json:"seasonal_p"`
+	SeasonalD    int `json:"seasonal_d"`
+	SeasonalQ    int `json:"seasonal_q"`
+	SeasonLength int `json:"season_length"`
+}
+
+func (o ARIMAOrder) String() string {
+	if o.SeasonLength == 0 {
+		return fmt.Sprintf("ARIMA(%d,%d,%d)", o.P, o.D, o.Q)
+	}
+	return fmt.Sprintf("SARIMA(%d,%d,%d)(%d,%d,%d)%d", o.P, o.D, o.Q, o.SeasonalP, o.SeasonalD, o.SeasonalQ, o.SeasonLength)
+}
+
+// Helper function for the number of ARMA coefficients the order estimates
+func (o ARIMAOrder) coefficients() int {
+	return o.P + o.Q + o.SeasonalP + o.SeasonalQ
+}
+
+// Helper function for the observations of the differenced series the AR terms need before
+// the first residual
+func (o ARIMAOrder) warmUp() int {
+	return o.P + o.SeasonalP*o.SeasonLength
+}
+
+// ARIMAModel is a SARIMA model fitted by conditional sum of squares
+type ARIMAModel struct {
+	Order      ARIMAOrder
+	AR         []float64 // phi, non-seasonal autoregressive coefficients
+	MA         []float64 // theta, non-seasonal moving average coefficients
+	SeasonalAR []float64
+	SeasonalMA []float64
+	Mean       float64 // mean of the differenced series
+	Sigma2     float64 // residual variance
+	AIC        float64
+	Residuals  []float64 // one-step errors on the differenced series, zero during warm-up
+
+	arFull []float64   // AR coefficients with seasonal terms multiplied out, arFull[k] applies to lag k+1
+	maFull []float64   // same for MA
+	levels [][]float64 // series before each differencing operation
+	lags   []int       // lag of each differencing operation
+	z      []float64   // demeaned differenced series
+	warmUp int         // residuals before this index are conditioned to zero
+}
+
+// FitARIMA estimates the coefficients for a fixed order by minimising the
+// conditional sum of squares with Nelder-Mead
+func FitARIMA(data []float64, order ARIMAOrder) (ARIMAModel, error) {
+	return fitARIMA(data, order, 0)
+}
+
+// Helper function to fit an order conditioning on at least warmUp observations of the
+// differenced series, so models of different orders can be fitted on the same sample
+func fitARIMA(data []float64, order ARIMAOrder, warmUp int) (ARIMAModel, error) {
+	if order.SeasonLength < 2 {
+		order.SeasonLength, order.SeasonalP, order.SeasonalD, order.SeasonalQ = 0, 0, 0, 0
+	}
+	model := ARIMAModel{Order: order}
+
+	// Difference d times at lag 1, then D times at the season length
+	series := data
+	for i := 0; i < order.D+order.SeasonalD; i++ {
+		lag := 1
+		if i >= order.D {
+			lag = order.SeasonLength
+		}
+		if len(series) <= lag {
+			return ARIMAModel{}, fmt.Errorf("series too short to difference for %s", order)
+		}
+		model.levels = append(model.levels, series)
+		model.lags = append(model.lags, lag)
+		series = difference(series, lag)
+	}
+
+	k := order.coefficients()
+	warmUp = max(warmUp, order.warmUp())
+	if len(series)-warmUp < k+3 {
+		return ARIMAModel{}, fmt.Errorf("series too short to estimate %s", order)
+	}
+	model.warmUp = warmUp
+
+	// A mean on a twice-differenced series would be a quadratic trend, leave it out
+	if order.D+order.SeasonalD <= 1 {
+		model.Mean = mean(series)
+	}
+	model.z = make([]float64, len(series))
+	for i, v := range series {
+		model.z[i] = v - model.Mean
+	}
+
+	// tanh keeps each coefficient inside (-1, 1) during the search
+	unpack := func(x []float64) {
+		next := 0
+		take := func(n int) []float64 {
+			coefs := make([]float64, n)
+			for i := range coefs {
+				coefs[i] = math.Tanh(x[next])
+				next++
+			}
+			return coefs
+		}
+		model.AR = take(order.P)
+		model.MA = take(order.Q)
+		model.SeasonalAR = take(order.SeasonalP)
+		model.SeasonalMA = take(order.SeasonalQ)
+		model.expand()
+	}
+	objective := func(x []float64) float64 {
+		unpack(x)
+		sse := model.css()
+		if math.IsNaN(sse) {
+			return math.Inf(1)
+		}
+		return sse
+	}
+
+	x := make([]float64, k)
+	if k > 0 {
+		x, _ = nelderMead(objective, x, 0.5, 300*k)
+	}
+	unpack(x)
+	sse := model.css()
+
+	n := float64(len(series) - warmUp)
+	params := float64(k + 1) // coefficients plus the residual variance
+	if order.D+order.SeasonalD <= 1 {
+		params++
+	}
+	model.Sigma2 = sse / n
+	model.AIC = n*math.Log(math.Max(model.Sigma2, 1e-300)) + 2*params
+	return model, nil
+}
+
+// AutoARIMA picks the differencing from the lag-1 autocorrelation, the season length by
+// the strongest seasonal autocorrelation, and the ARMA orders by AIC. Every candidate
+// drops the same warm-up observations, so their AICs are computed on the same sample.
+func AutoARIMA(data []float64, seasonLengths []int) (ARIMAModel, error) {
+	if len(data) < 4 {
+		return ARIMAModel{}, fmt.Errorf("need at least 4 observations, got %d", len(data))
+	}
+
+	// Difference while the series still behaves like a unit root
+	d := 0
+	differenced := data
+	for d < 2 && len(differenced) > 2 && Autocorrelation(differenced, 1) > unitRootACF {
+		differenced = difference(differenced, 1)
+		d++
+	}
+
+	var candidates []int
+	for _, length := range seasonLengths {
+		if length <= maxARIMASeasonLength {
+			candidates = append(candidates, length)
+		}
+	}
+	m, _ := DetectSeasonLength(differenced, candidates, 4)
+	seasonalD := 0
+	if m > 0 && variance(difference(differenced, m)) < variance(differenced) {
+		seasonalD = 1
+	}
+
+	maxSeasonalOrder := 0
+	if m > 0 {
+		maxSeasonalOrder = maxSeasonalARIMAOrder
+	}
+
+	var orders []ARIMAOrder
+	for p := 0; p <= maxARIMAOrder; p++ {
+		for q := 0; q <= maxARIMAOrder; q++ {
+			for sp := 0; sp <= maxSeasonalOrder; sp++ {
+				for sq := 0; sq <= maxSeasonalOrder; sq++ {
+					orders = append(orders, ARIMAOrder{P: p, D: d, Q: q, SeasonalP: sp, SeasonalD: seasonalD, SeasonalQ: sq, SeasonLength: m})
+				}
+			}
+		}
+	}
+
+	// Condition on the longest warm-up of any order the series is long enough to estimate
+	length := len(data) - d - seasonalD*m
+	warmUp := 0
+	for _, order := range orders {
+		if length-order.warmUp() >= order.coefficients()+3 {
+			warmUp = max(warmUp, order.warmUp())
+		}
+	}
+
+	var best ARIMAModel
+	found := false
+	for _, order := range orders {
+		model, err := fitARIMA(data, order, warmUp)
+		if err != nil {
+			continue
+		}
+		if !found || model.AIC < best.AIC {
+			best, found = model, true
+		}
+	}
+
+	if !found {
+		return ARIMAModel{}, fmt.Errorf("no ARIMA order could be fitted")
+	}
+	return best, nil
+}
+
+// Forecast returns the expected value for each of the next h steps
+func (m ARIMAModel) Forecast(h int) []float64 {
+	return m.integrate(m.extend(h, nil))
+}
+
+// Simulate draws one possible path by feeding bootstrapped residuals into the ARMA recursion
+func (m ARIMAModel) Simulate(h int, rng *rand.Rand) []float64 {
+	shocks := m.Residuals[m.warmUp:]
+	if len(shocks) == 0 {
+		return m.Forecast(h)
+	}
+	return m.integrate(m.extend(h, func() float64 { return shocks[rng.IntN(len(shocks))] }))
+}
+
+// ForecastVariance returns the forecast error variance for each of the next h steps
+// from the psi weights of the full model, differencing included
+func (m ARIMAModel) ForecastVariance(h int) []float64 {
+	// AR polynomial with the differencing operators multiplied in
+	ar := []float64{1}
+	for _, phi := range m.arFull {
+		ar = append(ar, -phi)
+	}
+	for _, lag := range m.lags {
+		op := make([]float64, lag+1)
+		op[0], op[lag] = 1, -1
+		ar = multiplyPolynomials(ar, op)
+	}
+
+	psi := make([]float64, h)
+	variance := make([]float64, h)
+	sum := 0.0
+	for j := 0; j < h; j++ {
+		if j == 0 {
+			psi[j] = 1
+		} else {
+			if j <= len(m.maFull) {
+				psi[j] = m.maFull[j-1]
+			}
+			for k := 1; k <= j && k < len(ar); k++ {
+				psi[j] -= ar[k] * psi[j-k]
+			}
+		}
+		sum += psi[j] * psi[j]
+		variance[j] = m.Sigma2 * sum
+	}
+	return variance
+}
+
+// Helper function to run the ARMA recursion h steps past the data. Future shocks
+// are zero unless shock is given. Returns the differenced-scale values, mean restored.
+func (m ARIMAModel) extend(h int, shock func() float64) []float64 {
+	z := append([]float64(nil), m.z...)
+	e := append([]float64(nil), m.Residuals...)
+	out := make([]float64, h)
+	for i := 0; i < h; i++ {
+		t := len(z)
+		v := 0.0
+		for k, phi := range m.arFull {
+			if t-k-1 >= 0 {
+				v += phi * z[t-k-1]
+			}
+		}
+		for k, theta := range m.maFull {
+			if t-k-1 >= 0 {
+				v += theta * e[t-k-1]
+			}
+		}
+		noise := 0.0
+		if shock != nil {
+			noise = shock()
+		}
+		z = append(z, v+noise)
+		e = append(e, noise)
+		out[i] = v + noise + m.Mean
+	}
+	return out
+}
+
+// Helper function to undo the differencing, innermost operation first
+func (m ARIMAModel) integrate(values []float64) []float64 {
+	for i := len(m.lags) - 1; i >= 0; i-- {
+		history := append([]float64(nil), m.levels[i]...)
+		integrated := make([]float64, len(values))
+		for h, v := range values {
+			integrated[h] = v + history[len(history)-m.lags[i]]
+			history = append(history, integrated[h])
+		}
+		values = integrated
+	}
+	return values
+}
+
+// Helper function to compute the residuals and their sum of squares for the current coefficients
+func (m *ARIMAModel) css() float64 {
+	m.Residuals = make([]float64, len(m.z))
+	sse := 0.0
+	for t := m.warmUp; t < len(m.z); t++ {
+		v := m.z[t]
+		for k, phi := range m.arFull {
+			v -= phi * m.z[t-k-1]
+		}
+		for k, theta := range m.maFull {
+			if t-k-1 >= 0 {
+				v -= theta * m.Residuals[t-k-1]
+			}
+		}
+		m.Residuals[t] = v
+		sse += v * v
+	}
+	return sse
+}
+
+// Helper function to multiply the seasonal and non-seasonal polynomials into single lag coefficient lists
+func (m *ARIMAModel) expand() {
+	s := m.Order.SeasonLength
+
+	ar := []float64{1}
+	for _, phi := range m.AR {
+		ar = append(ar, -phi)
+	}
+	sar := []float64{1}
+	for _, phi := range m.SeasonalAR {
+		sar = append(sar, make([]float64, s-1)...)
+		sar = append(sar, -phi)
+	}
+	arFull := multiplyPolynomials(ar, sar)
+	m.arFull = make([]float64, len(arFull)-1)
+	for k := 1; k < len(arFull); k++ {
+		m.arFull[k-1] = -arFull[k]
+	}
+
+	ma := append([]float64{1}, m.MA...)
+	sma := []float64{1}
+	for _, theta := range m.SeasonalMA {
+		sma = append(sma, make([]float64, s-1)...)
+		sma = append(sma, theta)
+	}
+	maFull := multiplyPolynomials(ma, sma)
+	m.maFull = maFull[1:]
+}
+
+// Fit summarises the in-sample error on the original scale
+func (m ARIMAModel) Fit() ARIMAFit {
+	residuals := m.Residuals[m.warmUp:]
+	sse := 0.0
+	for _, r := range residuals {
+		sse += r * r
+	}
+	rmse := 0.0
+	if len(residuals) > 0 {
+		rmse = math.Sqrt(sse / float64(len(residuals)))
+	}
+	return ARIMAFit{
+		Order:      m.Order,
+		Model:      m.Order.String(),
+		AR:         m.AR,
+		MA:         m.MA,
+		SeasonalAR: m.SeasonalAR,
+		SeasonalMA: m.SeasonalMA,
+		Sigma2:     m.Sigma2,
+		SSE:        sse,
+		RMSE:       rmse,
+		AIC:        m.AIC,
+	}
+}
+
+type ARIMAFit struct {
+	Order      ARIMAOrder `json:"order"`
+	Model      string     `json:"model"`
+	AR         []float64  `json:"ar"`
+	MA         []float64  `json:"ma"`
+	SeasonalAR []float64  `json:"seasonal_ar"`
+	SeasonalMA []float64  `json:"seasonal_ma"`
+	Sigma2     float64    `json:"sigma2"`
+	SSE        float64    `json:"sse"`
+	RMSE       float64    `json:"rmse"`
+	AIC        float64    `json:"aic"`
+}
+
+// ARIMAModelFit reports the selected order and fit for each forecasted field
+type ARIMAModelFit struct {
+	BidAskSpread ARIMAFit `json:"bid_ask_spread"`
+	Volume       ARIMAFit `json:"volume"`
+	BidPrice     ARIMAFit `json:"bid_price"`
+}
+
+// ARIMARecordModel holds an automatically selected ARIMA model for each forecasted field of models.Record
+type ARIMARecordModel struct {
+	BidAskSpread ARIMAModel
+	Volume       ARIMAModel
+	BidPrice     ARIMAModel
+	last         models.Record
+	step         time.Duration
+}
+
+// FitARIMARecords resamples records to one observation per step and selects an
+// ARIMA/SARIMA model for spread, volume and bid price. A zero step means daily.
+func FitARIMARecords(records []models.Record, step time.Duration) (ARIMARecordModel, error) {
+	if step <= 0 {
+		step = defaultStep
+	}
+	records = Resample(records, step)
+	if len(records) == 0 {
+		return ARIMARecordModel{}, fmt.Errorf("no records to fit")
+	}
+
+	seasonLengths := SeasonLengthsFor(step)
+	var fitted [3]ARIMAModel
+	selectors := []func(models.Record) float64{
+		func(r models.Record) float64 { return r.BidAskSpread },
+		func(r models.Record) float64 { return r.Volume },
+		func(r models.Record) float64 { return r.BidPrice },
+	}
+	for i, selector := range selectors {
+		model, err := AutoARIMA(extractField(records, selector), seasonLengths)
+		if err != nil {
+			return ARIMARecordModel{}, err
+		}
+		fitted[i] = model
+	}
+
+	return ARIMARecordModel{
+		BidAskSpread: fitted[0],
+		Volume:       fitted[1],
+		BidPrice:     fitted[2],
+		last:         records[len(records)-1],
+		step:         step,
+	}, nil
+}
+
+func (rm ARIMARecordModel) Fit() ARIMAModelFit {
+	return ARIMAModelFit{
+		BidAskSpread: rm.BidAskSpread.Fit(),
+		Volume:       rm.Volume.Fit(),
+		BidPrice:     rm.BidPrice.Fit(),
+	}
+}
+
+// Predict forecasts the records that follow the last fitted record
+func (rm ARIMARecordModel) Predict(opts ForecastOptions) []models.Record {
+	return predictRecords(rm.last, rm.step, [3]FieldModel{rm.BidAskSpread, rm.Volume, rm.BidPrice}, opts)
+}
+
+// Helper function to take lag differences of a series
+func difference(data []float64, lag int) []float64 {
+	if len(data) <= lag {
+		return nil
+	}
+	out := make([]float64, len(data)-lag)
+	for i := range out {
+		out[i] = data[i+lag] - data[i]
+	}
+	return out
+}
+
+// Helper function to multiply two polynomials given by their coefficients in increasing powers
+func multiplyPolynomials(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			out[i+j] += x * y
+		}
+	}
+	return out
+}
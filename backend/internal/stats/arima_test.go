@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Helper function for an AR(1) series x[t] = mu + phi*(x[t-1]-mu) + e[t] with unit normal shocks
+func ar1Series(n int, mu, phi float64, seed uint64) []float64 {
+	rng := rand.New(rand.NewPCG(seed, 1))
+	data := make([]float64, n)
+	prev := 0.0
+	for i := range data {
+		prev = phi*prev + rng.NormFloat64()
+		data[i] = mu + prev
+	}
+	return data
+}
+
+func TestFitARIMARecoversAR1(t *testing.T) {
+	data := ar1Series(1000, 10, 0.6, 1)
+	model, err := FitARIMA(data, ARIMAOrder{P: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(model.AR[0]-0.6) > 0.05 {
+		t.Errorf("AR coefficient = %.3f, want about 0.6", model.AR[0])
+	}
+	if math.Abs(model.Mean-10) > 0.3 {
+		t.Errorf("mean = %.3f, want about 10", model.Mean)
+	}
+	if math.Abs(model.Sigma2-1) > 0.15 {
+		t.Errorf("residual variance = %.3f, want about 1", model.Sigma2)
+	}
+
+	// The expected path decays from the last value back to the mean
+	forecast := model.Forecast(100)
+	if math.Abs(forecast[99]-model.Mean) > 1e-6 {
+		t.Errorf("long-run forecast = %.4f, want the mean %.4f", forecast[99], model.Mean)
+	}
+}
+
+func TestAutoARIMASelectsAR(t *testing.T) {
+	data := ar1Series(500, 10, 0.6, 2)
+	model, err := AutoARIMA(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Order.D != 0 || model.Order.P == 0 {
+		t.Errorf("selected %s for a stationary AR(1) series, want an undifferenced AR model", model.Order)
+	}
+}
+
+func TestFitARIMACommonWarmUp(t *testing.T) {
+	data := ar1Series(200, 0, 0.6, 3)
+	white, err := fitARIMA(data, ARIMAOrder{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar2, err := fitARIMA(data, ARIMAOrder{P: 2}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both models are scored on the 198 observations after the shared warm-up
+	for _, model := range []ARIMAModel{white, ar2} {
+		fit := model.Fit()
+		if got := fit.SSE / model.Sigma2; math.Abs(got-198) > 1e-6 {
+			t.Errorf("%s scored on %.1f observations, want 198", model.Order, got)
+		}
+	}
+	if ar2.AIC >= white.AIC {
+		t.Errorf("AR(2) AIC %.2f isn't below white noise %.2f on an AR(1) series", ar2.AIC, white.AIC)
+	}
+}
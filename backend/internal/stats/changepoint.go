@@ -0,0 +1,156 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Defaults for change-point detection, in units of the series' noise level
+const (
+	defaultMinSegment = 5   // shortest segment either detector will report
+	defaultCUSUMDrift = 0.5 // slack per observation before the CUSUM accumulates
+	defaultCUSUMLimit = 8.0 // accumulated deviation that signals a change, above the textbook 5 since the reference mean is estimated
+	cusumReference    = 20  // observations the reference mean of each segment is taken from
+)
+
+// PELT finds shifts in the mean of data with the Pruned Exact Linear Time algorithm
+// and returns the index at which each new segment starts. The series is scaled by a
+// robust estimate of its noise, so penalty is in standardised units; 0 uses a BIC
+// style 2*log(n). Segments shorter than minSegment (0 for the default) are not allowed.
+func PELT(data []float64, penalty float64, minSegment int) []int {
+	n := len(data)
+	if minSegment <= 0 {
+		minSegment = defaultMinSegment
+	}
+	if n < 2*minSegment {
+		return nil
+	}
+	if penalty <= 0 {
+		penalty = 2 * math.Log(float64(n))
+	}
+
+	// Prefix sums give the cost of any segment in constant time
+	scale := noiseScale(data)
+	sum := make([]float64, n+1)
+	sumSq := make([]float64, n+1)
+	for i, v := range data {
+		z := v / scale
+		sum[i+1] = sum[i] + z
+		sumSq[i+1] = sumSq[i] + z*z
+	}
+	cost := func(from, to int) float64 {
+		s := sum[to] - sum[from]
+		return sumSq[to] - sumSq[from] - s*s/float64(to-from)
+	}
+
+	best := make([]float64, n+1)
+	last := make([]int, n+1)
+	best[0] = -penalty
+	candidates := []int{0}
+	for t := minSegment; t <= n; t++ {
+		best[t] = math.Inf(1)
+		for _, s := range candidates {
+			if t-s < minSegment {
+				continue
+			}
+			total := best[s] + cost(s, t) + penalty
+			if total < best[t] {
+				best[t], last[t] = total, s
+			}
+		}
+		// Drop starts that can never beat the best split again
+		kept := candidates[:0]
+		for _, s := range candidates {
+			if t-s < minSegment || best[s]+cost(s, t) <= best[t] {
+				kept = append(kept, s)
+			}
+		}
+		candidates = append(kept, t)
+	}
+
+	var changes []int
+	for t := last[n]; t > 0; t = last[t] {
+		changes = append(changes, t)
+	}
+	sort.Ints(changes)
+	return changes
+}
+
+// CUSUM runs a two-sided cumulative sum test against the mean of the first 20
+// observations of each segment and returns the index at which each new segment starts.
+// drift and threshold are in units of the series' noise; 0 uses 0.5 and 8. After a
+// change the reference mean is re-estimated, so a lasting shift is reported once.
+func CUSUM(data []float64, drift, threshold float64) []int {
+	if drift <= 0 {
+		drift = defaultCUSUMDrift
+	}
+	if threshold <= 0 {
+		threshold = defaultCUSUMLimit
+	}
+	scale := noiseScale(data)
+
+	var changes []int
+	start := 0
+	for start+cusumReference <= len(data) {
+		reference := mean(data[start : start+cusumReference])
+		var up, down float64
+		upFrom, downFrom := start, start
+		change := -1
+		for t := start; t < len(data); t++ {
+			z := (data[t] - reference) / scale
+			up = math.Max(0, up+z-drift)
+			down = math.Max(0, down-z-drift)
+			if up == 0 {
+				upFrom = t + 1
+			}
+			if down == 0 {
+				downFrom = t + 1
+			}
+			// The change began where the alarming sum last left zero
+			if up > threshold {
+				change = upFrom
+				break
+			}
+			if down > threshold {
+				change = downFrom
+				break
+			}
+		}
+		if change <= start {
+			break
+		}
+		changes = append(changes, change)
+		start = change
+	}
+	return changes
+}
+
+// SegmentMeans returns the mean of each segment between the given change indices
+func SegmentMeans(data []float64, changes []int) []float64 {
+	bounds := append(append([]int{0}, changes...), len(data))
+	means := make([]float64, 0, len(bounds)-1)
+	for i := 1; i < len(bounds); i++ {
+		means = append(means, mean(data[bounds[i-1]:bounds[i]]))
+	}
+	return means
+}
+
+// Helper function to estimate the noise level of a series that may contain mean shifts,
+// from the median absolute first difference so the shifts themselves barely count
+func noiseScale(data []float64) float64 {
+	diffs := make([]float64, 0, len(data))
+	for i := 1; i < len(data); i++ {
+		diffs = append(diffs, math.Abs(data[i]-data[i-1]))
+	}
+	if len(diffs) > 0 {
+		sort.Float64s(diffs)
+		// MAD of a difference of two normals is 0.6745*sqrt(2)*sigma
+		if scale := diffs[len(diffs)/2] / (0.6745 * math.Sqrt2); scale > 0 {
+			return scale
+		}
+	}
+	if scale := math.Sqrt(variance(data)); scale > 0 {
+		return scale
+	}
+	return 1
+}
@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Helper function for a series of two means, each with alternating noise of ±0.1 so
+// every segment's mean is exact
+func stepSeries(length, at int, shift float64) []float64 {
+	data := make([]float64, length)
+	for i := range data {
+		data[i] = 0.1
+		if i%2 == 1 {
+			data[i] = -0.1
+		}
+		if i >= at {
+			data[i] += shift
+		}
+	}
+	return data
+}
+
+func TestPELTFindsStep(t *testing.T) {
+	data := stepSeries(40, 20, 5)
+	if got := PELT(data, 0, 0); !reflect.DeepEqual(got, []int{20}) {
+		t.Fatalf("PELT = %v, want [20]", got)
+	}
+	if got := PELT(data, 1e9, 0); got != nil {
+		t.Fatalf("PELT with a huge penalty = %v, want no changes", got)
+	}
+}
+
+func TestPELTPenalty(t *testing.T) {
+	// Splitting two segments of 10 saves n1*n2/n * (shift/scale)^2 = 5 * (shift/scale)^2
+	// in cost, so the break is kept only when the penalty is below that
+	const shift = 0.5
+	data := stepSeries(20, 10, shift)
+	scale := 0.2 / (0.6745 * math.Sqrt2)
+	saving := 5 * (shift / scale) * (shift / scale)
+
+	if got := PELT(data, saving-1, 5); !reflect.DeepEqual(got, []int{10}) {
+		t.Fatalf("PELT with penalty %.2f below the saving %.2f = %v, want [10]", saving-1, saving, got)
+	}
+	if got := PELT(data, saving+1, 5); got != nil {
+		t.Fatalf("PELT with penalty %.2f above the saving %.2f = %v, want no changes", saving+1, saving, got)
+	}
+}
+
+func TestPELTShortSeries(t *testing.T) {
+	if got := PELT(stepSeries(9, 5, 5), 0, 5); got != nil {
+		t.Fatalf("PELT on fewer than two minimum segments = %v, want nil", got)
+	}
+}
+
+func TestCUSUMFindsStepOnce(t *testing.T) {
+	data := stepSeries(80, 40, 5)
+	if got := CUSUM(data, 0, 0); !reflect.DeepEqual(got, []int{40}) {
+		t.Fatalf("CUSUM = %v, want [40]", got)
+	}
+	if got := CUSUM(stepSeries(80, 80, 0), 0, 0); got != nil {
+		t.Fatalf("CUSUM on a flat series = %v, want no changes", got)
+	}
+}
+
+func TestSegmentMeans(t *testing.T) {
+	means := SegmentMeans(stepSeries(40, 20, 5), []int{20})
+	if len(means) != 2 || math.Abs(means[0]) > 1e-12 || math.Abs(means[1]-5) > 1e-12 {
+		t.Fatalf("SegmentMeans = %v, want [0 5]", means)
+	}
+}
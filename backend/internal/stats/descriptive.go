@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// NormalQuantile returns the inverse CDF of the standard normal distribution at p
+func NormalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// Helper function to calculate the mean of a slice
+func mean(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range data {
+		sum += v
+	}
+	return sum / float64(len(data))
+}
+
+// Helper function to calculate the population variance of a slice
+func variance(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	m := mean(data)
+	sum := 0.0
+	for _, v := range data {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(data))
+}
+
+// Autocorrelation returns the sample autocorrelation of data at the given lag
+func Autocorrelation(data []float64, lag int) float64 {
+	if lag <= 0 || lag >= len(data) {
+		return 0
+	}
+	m := mean(data)
+	var num, den float64
+	for t, v := range data {
+		den += (v - m) * (v - m)
+		if t >= lag {
+			num += (v - m) * (data[t-lag] - m)
+		}
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// Quantile returns the p-quantile (0 to 1) of data by linear interpolation between order statistics
+func Quantile(data []float64, p float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), data...)
+	sort.Float64s(sorted)
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	if lower < 0 {
+		return sorted[0]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+// StdDev returns the sample standard deviation of data
+func StdDev(data []float64) float64 {
+	if len(data) < 2 {
+		return 0
+	}
+	return math.Sqrt(variance(data) * float64(len(data)) / float64(len(data)-1))
+}
+
+// Mean returns the arithmetic mean of data
+func Mean(data []float64) float64 {
+	return mean(data)
+}
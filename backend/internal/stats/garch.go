@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fewest returns GARCH is fitted on, below this the likelihood is too flat to trust
+const minGARCHObservations = 20
+
+// GARCHModel is a GARCH(1,1) conditional variance model:
+// variance[t] = Omega + Alpha*shock[t-1]^2 + Beta*variance[t-1]
+type GARCHModel struct {
+	Mu                  float64 // mean of the series, shocks are measured from it
+	Omega               float64
+	Alpha               float64
+	Beta                float64
+	LogLikelihood       float64
+	ConditionalVariance []float64 // in-sample conditional variance for each observation
+	lastShock           float64
+}
+
+// FitGARCH estimates a GARCH(1,1) on a series of returns or changes by Gaussian maximum
+// likelihood. The series is standardised before the search so tiny spread changes and
+// large price returns are equally well conditioned.
+func FitGARCH(returns []float64) (GARCHModel, error) {
+	if len(returns) < minGARCHObservations {
+		return GARCHModel{}, fmt.Errorf("need at least %d observations, got %d", minGARCHObservations, len(returns))
+	}
+	mu := mean(returns)
+	sd := math.Sqrt(variance(returns))
+	if sd == 0 {
+		return GARCHModel{}, fmt.Errorf("series has no variation")
+	}
+	standardised := make([]float64, len(returns))
+	for i, r := range returns {
+		standardised[i] = (r - mu) / sd
+	}
+
+	// Persistence (alpha+beta) and the alpha share are searched through logistic
+	// so the process stays stationary, omega through exp so it stays positive
+	build := func(x []float64) (float64, float64, float64) {
+		persistence := logistic(x[1])
+		share := logistic(x[2])
+		return math.Exp(x[0]), persistence * share, persistence * (1 - share)
+	}
+	objective := func(x []float64) float64 {
+		omega, alpha, beta := build(x)
+		ll, _ := garchLogLikelihood(standardised, omega, alpha, beta)
+		if math.IsNaN(ll) {
+			return math.Inf(1)
+		}
+		return -ll
+	}
+
+	start := []float64{math.Log(0.05), logit(0.95), logit(0.1)}
+	x, _ := nelderMead(objective, start, 0.5, 1000)
+	omega, alpha, beta := build(x)
+	ll, conditional := garchLogLikelihood(standardised, omega, alpha, beta)
+
+	// Back to the original scale
+	scale := sd * sd
+	for i := range conditional {
+		conditional[i] *= scale
+	}
+	return GARCHModel{
+		Mu:                  mu,
+		Omega:               omega * scale,
+		Alpha:               alpha,
+		Beta:                beta,
+		LogLikelihood:       ll - float64(len(returns))*math.Log(sd),
+		ConditionalVariance: conditional,
+		lastShock:           returns[len(returns)-1] - mu,
+	}, nil
+}
+
+// Persistence is how slowly volatility shocks decay, alpha + beta
+func (g GARCHModel) Persistence() float64 {
+	return g.Alpha + g.Beta
+}
+
+// LongRunVariance is the unconditional variance the process reverts to
+func (g GARCHModel) LongRunVariance() float64 {
+	return g.Omega / (1 - g.Persistence())
+}
+
+// ForecastVariance returns the conditional variance for each of the next h steps
+func (g GARCHModel) ForecastVariance(h int) []float64 {
+	forecast := make([]float64, h)
+	if h == 0 || len(g.ConditionalVariance) == 0 {
+		return forecast
+	}
+	last := g.ConditionalVariance[len(g.ConditionalVariance)-1]
+	next := g.Omega + g.Alpha*g.lastShock*g.lastShock + g.Beta*last
+	longRun := g.LongRunVariance()
+	for k := 0; k < h; k++ {
+		forecast[k] = longRun + math.Pow(g.Persistence(), float64(k))*(next-longRun)
+	}
+	return forecast
+}
+
+// Helper function to run the variance recursion and return the Gaussian log likelihood
+func garchLogLikelihood(shocks []float64, omega, alpha, beta float64) (float64, []float64) {
+	conditional := make([]float64, len(shocks))
+	conditional[0] = variance(shocks)
+	ll := 0.0
+	for t, e := range shocks {
+		if t > 0 {
+			conditional[t] = omega + alpha*shocks[t-1]*shocks[t-1] + beta*conditional[t-1]
+		}
+		if conditional[t] <= 0 {
+			return math.NaN(), conditional
+		}
+		ll -= 0.5 * (math.Log(2*math.Pi) + math.Log(conditional[t]) + e*e/conditional[t])
+	}
+	return ll, conditional
+}
+
+// LogReturns returns the log change between consecutive positive values, skipping
+// pairs where either value is not positive
+func LogReturns(data []float64) []float64 {
+	var returns []float64
+	for i := 1; i < len(data); i++ {
+		if data[i] > 0 && data[i-1] > 0 {
+			returns = append(returns, math.Log(data[i]/data[i-1]))
+		}
+	}
+	return returns
+}
+
+// Changes returns the first differences of data
+func Changes(data []float64) []float64 {
+	return difference(data, 1)
+}
@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Helper function to simulate GARCH(1,1) returns with unit normal innovations
+func garchSeries(n int, omega, alpha, beta float64, seed uint64) []float64 {
+	rng := rand.New(rand.NewPCG(seed, 1))
+	returns := make([]float64, n)
+	variance := omega / (1 - alpha - beta)
+	shock := 0.0
+	for i := range returns {
+		variance = omega + alpha*shock*shock + beta*variance
+		shock = math.Sqrt(variance) * rng.NormFloat64()
+		returns[i] = shock
+	}
+	return returns
+}
+
+func TestFitGARCHRecoversPersistence(t *testing.T) {
+	returns := garchSeries(3000, 0.05, 0.1, 0.85, 1)
+	model, err := FitGARCH(returns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := model.Persistence(); p >= 1 || math.Abs(p-0.95) > 0.05 {
+		t.Errorf("persistence = %.3f, want about 0.95 and below 1", p)
+	}
+	if math.Abs(model.Alpha-0.1) > 0.05 {
+		t.Errorf("alpha = %.3f, want about 0.1", model.Alpha)
+	}
+	if want := 1.0; math.Abs(model.LongRunVariance()-want) > 0.3 {
+		t.Errorf("long-run variance = %.3f, want about %.1f", model.LongRunVariance(), want)
+	}
+}
+
+func TestGARCHForecastRevertsToLongRun(t *testing.T) {
+	model, err := FitGARCH(garchSeries(1000, 0.05, 0.1, 0.85, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forecast := model.ForecastVariance(500)
+	longRun := model.LongRunVariance()
+	if math.Abs(forecast[499]-longRun) > 1e-3*longRun {
+		t.Errorf("variance 500 steps ahead = %.4f, want the long-run %.4f", forecast[499], longRun)
+	}
+	// Each step closes part of the gap, so the forecast moves monotonically
+	for k := 1; k < len(forecast); k++ {
+		if math.Abs(forecast[k]-longRun) > math.Abs(forecast[k-1]-longRun)+1e-12 {
+			t.Fatalf("step %d moves away from the long-run variance", k)
+		}
+	}
+}
+
+func TestFitGARCHNeedsObservations(t *testing.T) {
+	if _, err := FitGARCH(make([]float64, minGARCHObservations-1)); err == nil {
+		t.Error("fitted GARCH on too few observations")
+	}
+}
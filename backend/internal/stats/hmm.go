@@ -0,0 +1,323 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Floor on per-state variances (of standardised features) so a state can't collapse onto a single point
+const minHMMVariance = 1e-3
+
+// GaussianHMM is a hidden Markov model with diagonal Gaussian emissions.
+// Means and variances are in the units of the observations it was fitted on.
+type GaussianHMM struct {
+	Initial       []float64   // probability of starting in each state
+	Transition    [][]float64 // Transition[i][j] is the probability of moving from state i to j
+	Means         [][]float64 // Means[state][feature]
+	Variances     [][]float64 // Variances[state][feature]
+	LogLikelihood float64
+
+	center, scale []float64   // per-feature standardisation applied before fitting
+	mu, sigma2    [][]float64 // emission parameters on the standardised scale
+}
+
+// FitGaussianHMM trains an HMM with the given number of states using Baum-Welch.
+// Features are standardised first and states are seeded from quantiles of the first
+// feature, so fitting is deterministic.
+func FitGaussianHMM(observations [][]float64, states, maxIter int) (GaussianHMM, error) {
+	if states < 2 {
+		return GaussianHMM{}, fmt.Errorf("need at least 2 states")
+	}
+	if len(observations) < 10*states {
+		return GaussianHMM{}, fmt.Errorf("need at least %d observations, got %d", 10*states, len(observations))
+	}
+	features := len(observations[0])
+
+	model := GaussianHMM{center: make([]float64, features), scale: make([]float64, features)}
+	for f := 0; f < features; f++ {
+		column := make([]float64, len(observations))
+		for t, o := range observations {
+			column[t] = o[f]
+		}
+		model.center[f] = mean(column)
+		model.scale[f] = math.Sqrt(variance(column))
+		if model.scale[f] == 0 {
+			model.scale[f] = 1
+		}
+	}
+	obs := model.standardise(observations)
+	model.initialise(obs, states)
+
+	prev := math.Inf(-1)
+	for iter := 0; iter < maxIter; iter++ {
+		alpha, beta, scales := model.forwardBackward(obs)
+		ll := 0.0
+		for _, c := range scales {
+			ll += math.Log(c)
+		}
+		model.LogLikelihood = ll
+		if ll-prev < 1e-6 {
+			break
+		}
+		prev = ll
+		model.reestimate(obs, alpha, beta, scales)
+	}
+
+	// Report emission parameters in the original units
+	model.Means = make([][]float64, states)
+	model.Variances = make([][]float64, states)
+	for s := range model.mu {
+		model.Means[s] = make([]float64, features)
+		model.Variances[s] = make([]float64, features)
+		for f := range model.mu[s] {
+			model.Means[s][f] = model.mu[s][f]*model.scale[f] + model.center[f]
+			model.Variances[s][f] = model.sigma2[s][f] * model.scale[f] * model.scale[f]
+		}
+	}
+	return model, nil
+}
+
+// Viterbi returns the most likely state sequence for the observations
+func (m GaussianHMM) Viterbi(observations [][]float64) []int {
+	obs := m.standardise(observations)
+	states := len(m.Initial)
+	if len(obs) == 0 {
+		return nil
+	}
+
+	score := make([][]float64, len(obs))
+	from := make([][]int, len(obs))
+	for t := range obs {
+		score[t] = make([]float64, states)
+		from[t] = make([]int, states)
+		for j := 0; j < states; j++ {
+			emission := m.logEmission(obs[t], j)
+			if t == 0 {
+				score[t][j] = math.Log(m.Initial[j]) + emission
+				continue
+			}
+			best, bestFrom := math.Inf(-1), 0
+			for i := 0; i < states; i++ {
+				if v := score[t-1][i] + math.Log(m.Transition[i][j]); v > best {
+					best, bestFrom = v, i
+				}
+			}
+			score[t][j] = best + emission
+			from[t][j] = bestFrom
+		}
+	}
+
+	path := make([]int, len(obs))
+	last := len(obs) - 1
+	for j := 1; j < states; j++ {
+		if score[last][j] > score[last][path[last]] {
+			path[last] = j
+		}
+	}
+	for t := last; t > 0; t-- {
+		path[t-1] = from[t][path[t]]
+	}
+	return path
+}
+
+// Posteriors returns the probability of each state at each observation
+func (m GaussianHMM) Posteriors(observations [][]float64) [][]float64 {
+	obs := m.standardise(observations)
+	alpha, beta, _ := m.forwardBackward(obs)
+	gamma := make([][]float64, len(obs))
+	for t := range obs {
+		gamma[t] = make([]float64, len(m.Initial))
+		total := 0.0
+		for s := range gamma[t] {
+			gamma[t][s] = alpha[t][s] * beta[t][s]
+			total += gamma[t][s]
+		}
+		for s := range gamma[t] {
+			gamma[t][s] /= total
+		}
+	}
+	return gamma
+}
+
+// Helper function to seed states from quantile bands of the first feature
+func (m *GaussianHMM) initialise(obs [][]float64, states int) {
+	order := make([]int, len(obs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return obs[order[a]][0] < obs[order[b]][0] })
+
+	features := len(obs[0])
+	m.mu = make([][]float64, states)
+	m.sigma2 = make([][]float64, states)
+	m.Initial = make([]float64, states)
+	m.Transition = make([][]float64, states)
+	for s := 0; s < states; s++ {
+		band := order[s*len(obs)/states : (s+1)*len(obs)/states]
+		m.mu[s] = make([]float64, features)
+		m.sigma2[s] = make([]float64, features)
+		for f := 0; f < features; f++ {
+			column := make([]float64, len(band))
+			for i, idx := range band {
+				column[i] = obs[idx][f]
+			}
+			m.mu[s][f] = mean(column)
+			m.sigma2[s][f] = math.Max(variance(column), minHMMVariance)
+		}
+
+		m.Initial[s] = 1 / float64(states)
+		m.Transition[s] = make([]float64, states)
+		for j := range m.Transition[s] {
+			if j == s {
+				m.Transition[s][j] = 0.9
+			} else {
+				m.Transition[s][j] = 0.1 / float64(states-1)
+			}
+		}
+	}
+}
+
+// Helper function for the scaled forward-backward pass. scales[t] is the
+// normalising constant of alpha at t, so their logs sum to the log likelihood.
+func (m GaussianHMM) forwardBackward(obs [][]float64) ([][]float64, [][]float64, []float64) {
+	states := len(m.Initial)
+	alpha := make([][]float64, len(obs))
+	beta := make([][]float64, len(obs))
+	scales := make([]float64, len(obs))
+
+	emissions := make([][]float64, len(obs))
+	for t := range obs {
+		emissions[t] = make([]float64, states)
+		for s := 0; s < states; s++ {
+			// Floor keeps an outlier from zeroing every state
+			emissions[t][s] = math.Max(math.Exp(m.logEmission(obs[t], s)), 1e-300)
+		}
+	}
+
+	for t := range obs {
+		alpha[t] = make([]float64, states)
+		for j := 0; j < states; j++ {
+			if t == 0 {
+				alpha[t][j] = m.Initial[j] * emissions[t][j]
+				continue
+			}
+			sum := 0.0
+			for i := 0; i < states; i++ {
+				sum += alpha[t-1][i] * m.Transition[i][j]
+			}
+			alpha[t][j] = sum * emissions[t][j]
+		}
+		for _, v := range alpha[t] {
+			scales[t] += v
+		}
+		for j := range alpha[t] {
+			alpha[t][j] /= scales[t]
+		}
+	}
+
+	last := len(obs) - 1
+	beta[last] = make([]float64, states)
+	for j := range beta[last] {
+		beta[last][j] = 1
+	}
+	for t := last - 1; t >= 0; t-- {
+		beta[t] = make([]float64, states)
+		for i := 0; i < states; i++ {
+			for j := 0; j < states; j++ {
+				beta[t][i] += m.Transition[i][j] * emissions[t+1][j] * beta[t+1][j]
+			}
+			beta[t][i] /= scales[t+1]
+		}
+	}
+	return alpha, beta, scales
+}
+
+// Helper function for one Baum-Welch re-estimation step
+func (m *GaussianHMM) reestimate(obs [][]float64, alpha, beta [][]float64, scales []float64) {
+	states := len(m.Initial)
+	features := len(obs[0])
+
+	gamma := make([][]float64, len(obs))
+	for t := range obs {
+		gamma[t] = make([]float64, states)
+		total := 0.0
+		for s := 0; s < states; s++ {
+			gamma[t][s] = alpha[t][s] * beta[t][s]
+			total += gamma[t][s]
+		}
+		for s := range gamma[t] {
+			gamma[t][s] /= total
+		}
+	}
+
+	// Expected transitions
+	xiSum := make([][]float64, states)
+	for i := range xiSum {
+		xiSum[i] = make([]float64, states)
+	}
+	for t := 0; t < len(obs)-1; t++ {
+		for i := 0; i < states; i++ {
+			for j := 0; j < states; j++ {
+				emission := math.Max(math.Exp(m.logEmission(obs[t+1], j)), 1e-300)
+				xiSum[i][j] += alpha[t][i] * m.Transition[i][j] * emission * beta[t+1][j] / scales[t+1]
+			}
+		}
+	}
+
+	for i := 0; i < states; i++ {
+		m.Initial[i] = gamma[0][i]
+		rowTotal := 0.0
+		for j := 0; j < states; j++ {
+			rowTotal += xiSum[i][j]
+		}
+		for j := 0; j < states; j++ {
+			if rowTotal > 0 {
+				m.Transition[i][j] = math.Max(xiSum[i][j]/rowTotal, 1e-6)
+			}
+		}
+
+		weight := 0.0
+		for t := range obs {
+			weight += gamma[t][i]
+		}
+		if weight == 0 {
+			continue
+		}
+		for f := 0; f < features; f++ {
+			mu := 0.0
+			for t := range obs {
+				mu += gamma[t][i] * obs[t][f]
+			}
+			mu /= weight
+			v := 0.0
+			for t := range obs {
+				v += gamma[t][i] * (obs[t][f] - mu) * (obs[t][f] - mu)
+			}
+			m.mu[i][f] = mu
+			m.sigma2[i][f] = math.Max(v/weight, minHMMVariance)
+		}
+	}
+}
+
+// Helper function for the log density of a standardised observation under a state
+func (m GaussianHMM) logEmission(o []float64, state int) float64 {
+	ll := 0.0
+	for f, x := range o {
+		mu, v := m.mu[state][f], m.sigma2[state][f]
+		ll -= 0.5 * (math.Log(2*math.Pi*v) + (x-mu)*(x-mu)/v)
+	}
+	return ll
+}
+
+// Helper function to standardise observations with the fitted centre and scale
+func (m GaussianHMM) standardise(observations [][]float64) [][]float64 {
+	out := make([][]float64, len(observations))
+	for t, o := range observations {
+		out[t] = make([]float64, len(o))
+		for f, x := range o {
+			out[t][f] = (x - m.center[f]) / m.scale[f]
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Helper function for a one-feature series that sits near 0, jumps to 5 and comes back
+func twoLevelSeries() (observations [][]float64, levels []int) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	for i := 0; i < 300; i++ {
+		level := 0
+		if i >= 100 && i < 200 {
+			level = 1
+		}
+		observations = append(observations, []float64{5*float64(level) + 0.3*rng.NormFloat64()})
+		levels = append(levels, level)
+	}
+	return observations, levels
+}
+
+func TestGaussianHMMSeparatesLevels(t *testing.T) {
+	observations, levels := twoLevelSeries()
+	model, err := FitGaussianHMM(observations, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// State numbering is arbitrary, so line it up with the first observation
+	low := model.Viterbi(observations)[0]
+	if math.Abs(model.Means[low][0]) > 0.2 || math.Abs(model.Means[1-low][0]-5) > 0.2 {
+		t.Errorf("state means %.2f and %.2f, want about 0 and 5", model.Means[low][0], model.Means[1-low][0])
+	}
+	for i, state := range model.Viterbi(observations) {
+		if (state == low) != (levels[i] == 0) {
+			t.Fatalf("observation %d decoded to the wrong level", i)
+		}
+	}
+
+	for i, row := range model.Transition {
+		sum := 0.0
+		for _, p := range row {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("transition row %d sums to %v", i, sum)
+		}
+		if row[i] < 0.9 {
+			t.Errorf("state %d stays put with probability %.3f, want the long runs to be persistent", i, row[i])
+		}
+	}
+
+	for i, posterior := range model.Posteriors(observations) {
+		if math.Abs(posterior[0]+posterior[1]-1) > 1e-9 {
+			t.Fatalf("posteriors at %d sum to %v", i, posterior[0]+posterior[1])
+		}
+	}
+}
+
+func TestFitGaussianHMMNeedsObservations(t *testing.T) {
+	observations, _ := twoLevelSeries()
+	if _, err := FitGaussianHMM(observations[:19], 2, 100); err == nil {
+		t.Error("fitted a 2 state HMM on 19 observations")
+	}
+}
@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"fmt"
 	"math"
 	"math/rand/v2"
 	"time"
@@ -8,119 +9,437 @@ import (
 	"github.com/bedminer1/liquidity_tracker/internal/models"
 )
 
-func GeneratePredictions(records []models.Record, intervals int) []models.Record {
-	if len(records) == 0 {
-		return nil // No historical data to base predictions on
-	}
+// SeasonalType selects how the seasonal component combines with level and trend
+type SeasonalType string
 
-	lastRecord := records[len(records)-1]
-	lastTimestamp := lastRecord.Timestamp
+const (
+	Additive       SeasonalType = "additive"
+	Multiplicative SeasonalType = "multiplicative"
+)
 
-	// Calculate trend and seasonality
-	spreads := extractField(records, func(r models.Record) float64 { return r.BidAskSpread })
-	movingAverage := calculateMovingAverage(spreads, 30)
+// Starting point for the smoothing parameter search
+const (
+	defaultAlpha = 0.3
+	defaultBeta  = 0.1
+	defaultGamma = 0.1
+)
 
-	trendVolume := calculateTrend(records, func(r models.Record) float64 { return r.Volume })
-	seasonalityVolume := calculateSeasonality(records, func(r models.Record) float64 { return r.Volume })
+type HoltWintersParams struct {
+	Alpha        float64      `json:"alpha"`         // level smoothing
+	Beta         float64      `json:"beta"`          // trend smoothing
+	Gamma        float64      `json:"gamma"`         // seasonal smoothing
+	SeasonLength int          `json:"season_length"` // 0 disables the seasonal component
+	Seasonal     SeasonalType `json:"seasonal"`
+}
 
-	// Calculate historical range for scaling noise
-	minVolume, maxVolume := calculateRange(records, func(r models.Record) float64 { return r.Volume })
-	volumeRange := maxVolume - minVolume
+// HoltWintersModel holds the smoothed state after running over a series
+type HoltWintersModel struct {
+	Params    HoltWintersParams
+	Level     float64
+	Trend     float64
+	Seasonals []float64 // seasonal index for each position in the cycle (t mod SeasonLength)
+	Fitted    []float64 // one-step-ahead in-sample forecasts
+	Residuals []float64 // observed minus fitted
+	SSE       float64
+	n         int // number of observations the model was fitted on
+}
 
-	var predictions []models.Record
+// FitHoltWinters runs triple exponential smoothing over data with the given parameters.
+// Series too short for two full seasons fall back to Holt's linear (non-seasonal) method.
+func FitHoltWinters(data []float64, params HoltWintersParams) (HoltWintersModel, error) {
+	if len(data) < 2 {
+		return HoltWintersModel{}, fmt.Errorf("need at least 2 observations, got %d", len(data))
+	}
+	for _, p := range []float64{params.Alpha, params.Beta, params.Gamma} {
+		if p < 0 || p > 1 {
+			return HoltWintersModel{}, fmt.Errorf("smoothing parameters must be within [0, 1]")
+		}
+	}
+	if params.Seasonal == "" {
+		params.Seasonal = Additive
+	}
+	if params.Seasonal == Multiplicative {
+		for _, v := range data {
+			if v <= 0 {
+				return HoltWintersModel{}, fmt.Errorf("multiplicative seasonality requires strictly positive data")
+			}
+		}
+	}
+	m := params.SeasonLength
+	if m < 2 || len(data) < 2*m {
+		params.SeasonLength = 0
+		m = 0
+	}
 
-	// Generate predictions
-	for i := 1; i <= intervals; i++ {
-		predictedTimestamp := lastTimestamp.Add(time.Duration(i) * time.Hour * 24)
+	model := HoltWintersModel{Params: params, n: len(data)}
+	model.Level, model.Trend, model.Seasonals = initialState(data, params)
 
-		// Spread prediction
-		predictedSpread := movingAverage * (0.9999 + rand.Float64()*0.0002)
-		randomChoice := rand.Float64()
-		if randomChoice < 0.05 {
-			// 10% chance of a spike (increase by 80%-200%)
-			spikeFactor := 1.1 + rand.Float64()*1.6
-			predictedSpread *= spikeFactor
-		}
+	model.Fitted = make([]float64, len(data))
+	model.Residuals = make([]float64, len(data))
+	for t, y := range data {
+		level, trend := model.Level, model.Trend
+		season := model.seasonal(t)
 
-		// Volume prediction
-		volumeSeasonalIndex := i % len(seasonalityVolume)
-		predictedVolume := lastRecord.Volume + trendVolume*float64(i) + seasonalityVolume[volumeSeasonalIndex]
-		volumeVolatility := (rand.Float64() - 0.5) * volumeRange * 0.2 // ±5% noise
-		predictedVolume = math.Max(predictedVolume+volumeVolatility, minVolume) // Ensure non-negative
+		yhat := model.combine(level+trend, season)
+		model.Fitted[t] = yhat
+		model.Residuals[t] = y - yhat
+		model.SSE += (y - yhat) * (y - yhat)
 
-		// Bid price prediction
-		bidPriceVolatility := (rand.Float64() - 0.5) * lastRecord.BidPrice * 0.2 // ±2% noise
-		predictedBidPrice := math.Max(lastRecord.BidPrice+bidPriceVolatility, 0)
+		switch {
+		case m == 0:
+			model.Level = params.Alpha*y + (1-params.Alpha)*(level+trend)
+		case params.Seasonal == Multiplicative:
+			model.Level = params.Alpha*(y/season) + (1-params.Alpha)*(level+trend)
+		default:
+			model.Level = params.Alpha*(y-season) + (1-params.Alpha)*(level+trend)
+		}
+		model.Trend = params.Beta*(model.Level-level) + (1-params.Beta)*trend
 
-		// Append prediction
-		predictions = append(predictions, models.Record{
-			AssetType:    lastRecord.AssetType,
-			Timestamp:    predictedTimestamp,
-			BidAskSpread: predictedSpread,
-			Volume:       predictedVolume,
-			BidPrice:     predictedBidPrice,
-		})
+		if m > 0 {
+			if params.Seasonal == Multiplicative {
+				if model.Level != 0 {
+					model.Seasonals[t%m] = params.Gamma*(y/model.Level) + (1-params.Gamma)*season
+				}
+			} else {
+				model.Seasonals[t%m] = params.Gamma*(y-model.Level) + (1-params.Gamma)*season
+			}
+		}
 	}
 
-	return predictions
+	return model, nil
 }
 
-// Helper function to calculate a moving average
-func calculateMovingAverage(data []float64, windowSize int) float64 {
-	if len(data) < windowSize {
-		windowSize = len(data)
+// Forecast returns the expected value for each of the next h steps
+func (m HoltWintersModel) Forecast(h int) []float64 {
+	forecast := make([]float64, h)
+	for k := 1; k <= h; k++ {
+		forecast[k-1] = m.combine(m.Level+float64(k)*m.Trend, m.seasonal(m.n+k-1))
 	}
+	return forecast
+}
 
-	sum := 0.0
-	for i := len(data) - windowSize; i < len(data); i++ {
-		sum += data[i]
+// ForecastVariance approximates the forecast error variance for each of the next h steps
+// from the residual variance, using the additive Holt-Winters error propagation.
+// Multiplicative models reuse the same propagation on their absolute residuals.
+func (m HoltWintersModel) ForecastVariance(h int) []float64 {
+	variance := make([]float64, h)
+	if len(m.Residuals) == 0 {
+		return variance
 	}
+	sigma2 := m.SSE / float64(len(m.Residuals))
+
+	sum := 1.0
+	for k := 1; k <= h; k++ {
+		variance[k-1] = sigma2 * sum
 
-	return sum / float64(windowSize)
+		// Weight of a shock k steps back on the next forecast
+		c := m.Params.Alpha * (1 + float64(k)*m.Params.Beta)
+		if m.Params.SeasonLength > 0 && k%m.Params.SeasonLength == 0 {
+			c += m.Params.Gamma
+		}
+		sum += c * c
+	}
+	return variance
 }
 
-// Helper function to extract a field from records
-func extractField(records []models.Record, selector func(models.Record) float64) []float64 {
-	var result []float64
-	for _, record := range records {
-		result = append(result, selector(record))
+// Simulate draws one possible path for the next h steps by feeding
+// bootstrapped in-sample residuals back through the smoothing equations
+func (m HoltWintersModel) Simulate(h int, rng *rand.Rand) []float64 {
+	if len(m.Residuals) == 0 {
+		return m.Forecast(h)
+	}
+
+	level, trend := m.Level, m.Trend
+	seasonals := append([]float64(nil), m.Seasonals...)
+	season := func(t int) float64 {
+		if m.Params.SeasonLength == 0 {
+			return m.seasonal(t)
+		}
+		return seasonals[t%m.Params.SeasonLength]
 	}
-	return result
+
+	path := make([]float64, h)
+	for k := 0; k < h; k++ {
+		t := m.n + k
+		s := season(t)
+		y := m.combine(level+trend, s) + m.Residuals[rng.IntN(len(m.Residuals))]
+		path[k] = y
+
+		prevLevel := level
+		switch {
+		case m.Params.SeasonLength == 0:
+			level = m.Params.Alpha*y + (1-m.Params.Alpha)*(level+trend)
+		case m.Params.Seasonal == Multiplicative:
+			level = m.Params.Alpha*(y/s) + (1-m.Params.Alpha)*(level+trend)
+		default:
+			level = m.Params.Alpha*(y-s) + (1-m.Params.Alpha)*(level+trend)
+		}
+		trend = m.Params.Beta*(level-prevLevel) + (1-m.Params.Beta)*trend
+
+		if m.Params.SeasonLength > 0 {
+			i := t % m.Params.SeasonLength
+			if m.Params.Seasonal == Multiplicative {
+				if level != 0 {
+					seasonals[i] = m.Params.Gamma*(y/level) + (1-m.Params.Gamma)*s
+				}
+			} else {
+				seasonals[i] = m.Params.Gamma*(y-level) + (1-m.Params.Gamma)*s
+			}
+		}
+	}
+	return path
 }
 
-// Helper function to calculate the trend
-func calculateTrend(records []models.Record, fieldSelector func(models.Record) float64) float64 {
-	if len(records) < 2 {
+// Helper function to look up the seasonal index for time t
+func (m HoltWintersModel) seasonal(t int) float64 {
+	if m.Params.SeasonLength == 0 {
+		if m.Params.Seasonal == Multiplicative {
+			return 1
+		}
 		return 0
 	}
-	first := fieldSelector(records[0])
-	last := fieldSelector(records[len(records)-1])
-	return (last - first) / float64(len(records))
+	return m.Seasonals[t%m.Params.SeasonLength]
+}
+
+// Helper function to apply the seasonal index to a level+trend value
+func (m HoltWintersModel) combine(base, season float64) float64 {
+	if m.Params.Seasonal == Multiplicative {
+		return base * season
+	}
+	return base + season
+}
+
+// Helper function to derive starting level, trend and seasonal indices.
+// Level and trend are placed one step before the first observation.
+func initialState(data []float64, params HoltWintersParams) (float64, float64, []float64) {
+	m := params.SeasonLength
+	if m == 0 {
+		return data[0] - (data[1] - data[0]), data[1] - data[0], nil
+	}
+
+	// Average of each complete season
+	seasons := len(data) / m
+	averages := make([]float64, seasons)
+	for j := 0; j < seasons; j++ {
+		averages[j] = mean(data[j*m : (j+1)*m])
+	}
+
+	trend := (averages[1] - averages[0]) / float64(m)
+	// averages[0] sits at the middle of the first season, step back to t = -1
+	level := averages[0] - trend*float64(m+1)/2
+
+	seasonals := make([]float64, m)
+	for i := 0; i < m; i++ {
+		sum := 0.0
+		for j := 0; j < seasons; j++ {
+			if params.Seasonal == Multiplicative {
+				sum += data[j*m+i] / averages[j]
+			} else {
+				sum += data[j*m+i] - averages[j]
+			}
+		}
+		seasonals[i] = sum / float64(seasons)
+	}
+
+	return level, trend, seasonals
+}
+
+// OptimizeHoltWinters searches for the smoothing parameters, season length and
+// seasonal type that best describe data. Alpha/beta/gamma are tuned with Nelder-Mead
+// on the in-sample SSE, and the candidate structures are compared by AIC so longer
+// seasons have to earn their extra state.
+func OptimizeHoltWinters(data []float64, seasonLengths []int) (HoltWintersModel, error) {
+	if len(data) < 2 {
+		return HoltWintersModel{}, fmt.Errorf("need at least 2 observations, got %d", len(data))
+	}
+
+	var best HoltWintersModel
+	bestAIC := math.Inf(1)
+	found := false
+
+	candidates := append([]int{0}, seasonLengths...)
+	for _, seasonal := range []SeasonalType{Additive, Multiplicative} {
+		for _, m := range candidates {
+			if m != 0 && (m < 2 || len(data) < 2*m) {
+				continue
+			}
+			if m == 0 && seasonal == Multiplicative {
+				continue // identical to the additive non-seasonal model
+			}
+			model, err := optimizeSmoothing(data, m, seasonal)
+			if err != nil {
+				continue
+			}
+			if aic := model.Fit().AIC; aic < bestAIC {
+				best, bestAIC, found = model, aic, true
+			}
+		}
+	}
+
+	if !found {
+		return HoltWintersModel{}, fmt.Errorf("no Holt-Winters configuration could be fitted")
+	}
+	return best, nil
 }
 
-// Helper function to calculate seasonality
-func calculateSeasonality(records []models.Record, fieldSelector func(models.Record) float64) []float64 {
-	seasonLength := min(10, rand.IntN(int(math.Min(20, float64(len(records)))))) // Use up to 10 periods for seasonality
-	seasonality := make([]float64, seasonLength)
+// Helper function to tune alpha/beta/gamma for a fixed season length and type
+func optimizeSmoothing(data []float64, seasonLength int, seasonal SeasonalType) (HoltWintersModel, error) {
+	build := func(x []float64) HoltWintersParams {
+		params := HoltWintersParams{
+			Alpha:        logistic(x[0]),
+			Beta:         logistic(x[1]),
+			SeasonLength: seasonLength,
+			Seasonal:     seasonal,
+		}
+		if seasonLength > 0 {
+			params.Gamma = logistic(x[2])
+		}
+		return params
+	}
+
+	// Fail fast on data the configuration can't handle
+	start := []float64{logit(defaultAlpha), logit(defaultBeta)}
+	if seasonLength > 0 {
+		start = append(start, logit(defaultGamma))
+	}
+	if _, err := FitHoltWinters(data, build(start)); err != nil {
+		return HoltWintersModel{}, err
+	}
 
-	for i := 0; i < seasonLength; i++ {
-		seasonality[i] = fieldSelector(records[i]) - calculateTrend(records, fieldSelector)*float64(i)
+	objective := func(x []float64) float64 {
+		model, err := FitHoltWinters(data, build(x))
+		if err != nil || math.IsNaN(model.SSE) {
+			return math.Inf(1)
+		}
+		return model.SSE
 	}
+	x, _ := nelderMead(objective, start, 1.0, 200*len(start))
 
-	return seasonality
+	return FitHoltWinters(data, build(x))
 }
 
-// Helper function to calculate the range of a field
-func calculateRange(records []models.Record, fieldSelector func(models.Record) float64) (float64, float64) {
-	minVal, maxVal := math.MaxFloat64, -math.MaxFloat64
-	for _, r := range records {
-		value := fieldSelector(r)
-		if value < minVal {
-			minVal = value
+type FitStats struct {
+	SSE  float64 `json:"sse"`
+	RMSE float64 `json:"rmse"`
+	MAPE float64 `json:"mape"` // percentage, skips zero observations
+	AIC  float64 `json:"aic"`
+}
+
+// Fit summarises how well the model tracks the data it was fitted on
+func (m HoltWintersModel) Fit() FitStats {
+	n := float64(len(m.Residuals))
+	if n == 0 {
+		return FitStats{}
+	}
+
+	absPct, counted := 0.0, 0
+	for t, r := range m.Residuals {
+		observed := m.Fitted[t] + r
+		if observed != 0 {
+			absPct += math.Abs(r / observed)
+			counted++
 		}
-		if value > maxVal {
-			maxVal = value
+	}
+	mape := 0.0
+	if counted > 0 {
+		mape = absPct / float64(counted) * 100
+	}
+
+	// Smoothing parameters plus initial level, trend and seasonal states
+	k := 4.0
+	if m.Params.SeasonLength > 0 {
+		k = 5 + float64(m.Params.SeasonLength)
+	}
+
+	return FitStats{
+		SSE:  m.SSE,
+		RMSE: math.Sqrt(m.SSE / n),
+		MAPE: mape,
+		AIC:  n*math.Log(math.Max(m.SSE, 1e-300)/n) + 2*k,
+	}
+}
+
+type FieldFit struct {
+	Params HoltWintersParams `json:"params"`
+	FitStats
+}
+
+// ModelFit reports the fitted parameters and in-sample error for each forecasted field
+type ModelFit struct {
+	BidAskSpread FieldFit `json:"bid_ask_spread"`
+	Volume       FieldFit `json:"volume"`
+	BidPrice     FieldFit `json:"bid_price"`
+}
+
+// RecordModel holds a fitted Holt-Winters model for each forecasted field of models.Record
+type RecordModel struct {
+	BidAskSpread HoltWintersModel
+	Volume       HoltWintersModel
+	BidPrice     HoltWintersModel
+	last         models.Record
+	step         time.Duration
+}
+
+// FitRecords resamples records to one observation per step and optimises a separate
+// model for spread, volume and bid price. A zero step means daily.
+func FitRecords(records []models.Record, step time.Duration) (RecordModel, error) {
+	if step <= 0 {
+		step = defaultStep
+	}
+	records = Resample(records, step)
+	if len(records) == 0 {
+		return RecordModel{}, fmt.Errorf("no records to fit")
+	}
+
+	seasonLengths := SeasonLengthsFor(step)
+	return RecordModel{
+		BidAskSpread: fitField(records, seasonLengths, func(r models.Record) float64 { return r.BidAskSpread }),
+		Volume:       fitField(records, seasonLengths, func(r models.Record) float64 { return r.Volume }),
+		BidPrice:     fitField(records, seasonLengths, func(r models.Record) float64 { return r.BidPrice }),
+		last:         records[len(records)-1],
+		step:         step,
+	}, nil
+}
+
+func (rm RecordModel) Fit() ModelFit {
+	return ModelFit{
+		BidAskSpread: FieldFit{Params: rm.BidAskSpread.Params, FitStats: rm.BidAskSpread.Fit()},
+		Volume:       FieldFit{Params: rm.Volume.Params, FitStats: rm.Volume.Fit()},
+		BidPrice:     FieldFit{Params: rm.BidPrice.Params, FitStats: rm.BidPrice.Fit()},
+	}
+}
+
+// Predict forecasts the records that follow the last fitted record
+func (rm RecordModel) Predict(opts ForecastOptions) []models.Record {
+	return predictRecords(rm.last, rm.step, [3]FieldModel{rm.BidAskSpread, rm.Volume, rm.BidPrice}, opts)
+}
+
+// GeneratePredictions fits each field on records resampled to opts.Step and forecasts
+// opts.Intervals records ahead.
+// Identical records and options always produce identical predictions.
+func GeneratePredictions(records []models.Record, opts ForecastOptions) ([]models.Record, ModelFit) {
+	if len(records) == 0 || opts.Intervals <= 0 {
+		return nil, ModelFit{} // No historical data to base predictions on
+	}
+
+	model, err := FitRecords(records, opts.Step)
+	if err != nil {
+		return nil, ModelFit{}
+	}
+	return model.Predict(opts), model.Fit()
+}
+
+// Helper function to fit a single field, falling back to repeating the
+// last value when the series is too short to fit a model
+func fitField(records []models.Record, seasonLengths []int, selector func(models.Record) float64) HoltWintersModel {
+	data := extractField(records, selector)
+	model, err := OptimizeHoltWinters(data, seasonLengths)
+	if err != nil {
+		return HoltWintersModel{
+			Params: HoltWintersParams{Alpha: 1, Seasonal: Additive},
+			Level:  data[len(data)-1],
+			n:      len(data),
 		}
 	}
-	return minVal, maxVal
-}
\ No newline at end of file
+	return model
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// nelderMead minimises f starting from x0 using the downhill simplex method.
+// step is the initial offset used to build the simplex around x0.
+func nelderMead(f func([]float64) float64, x0 []float64, step float64, maxIter int) ([]float64, float64) {
+	const (
+		reflection  = 1.0
+		expansion   = 2.0
+		contraction = 0.5
+		shrink      = 0.5
+		tolerance   = 1e-10
+	)
+
+	dim := len(x0)
+	type vertex struct {
+		x []float64
+		f float64
+	}
+
+	// Build the initial simplex
+	simplex := make([]vertex, dim+1)
+	simplex[0] = vertex{x: append([]float64(nil), x0...), f: f(x0)}
+	for i := 0; i < dim; i++ {
+		x := append([]float64(nil), x0...)
+		x[i] += step
+		simplex[i+1] = vertex{x: x, f: f(x)}
+	}
+
+	// Helper to move from the centroid towards/away from a vertex
+	along := func(centroid, x []float64, coef float64) []float64 {
+		out := make([]float64, dim)
+		for i := range out {
+			out[i] = centroid[i] + coef*(x[i]-centroid[i])
+		}
+		return out
+	}
+
+	for iter := 0; iter < maxIter; iter++ {
+		sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+		if math.Abs(simplex[dim].f-simplex[0].f) <= tolerance*(math.Abs(simplex[0].f)+tolerance) {
+			break
+		}
+
+		// Centroid of every vertex except the worst
+		centroid := make([]float64, dim)
+		for _, v := range simplex[:dim] {
+			for i := range centroid {
+				centroid[i] += v.x[i] / float64(dim)
+			}
+		}
+
+		worst := simplex[dim]
+		reflected := along(centroid, worst.x, -reflection)
+		fr := f(reflected)
+
+		switch {
+		case fr < simplex[0].f:
+			expanded := along(centroid, worst.x, -expansion)
+			if fe := f(expanded); fe < fr {
+				simplex[dim] = vertex{expanded, fe}
+			} else {
+				simplex[dim] = vertex{reflected, fr}
+			}
+		case fr < simplex[dim-1].f:
+			simplex[dim] = vertex{reflected, fr}
+		default:
+			contracted := along(centroid, worst.x, contraction)
+			if fc := f(contracted); fc < worst.f {
+				simplex[dim] = vertex{contracted, fc}
+				continue
+			}
+			// Shrink everything towards the best vertex
+			for i := 1; i <= dim; i++ {
+				x := along(simplex[0].x, simplex[i].x, shrink)
+				simplex[i] = vertex{x, f(x)}
+			}
+		}
+	}
+
+	sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+	return simplex[0].x, simplex[0].f
+}
+
+// Helper functions to map an unbounded value into (0, 1) and back,
+// so bounded parameters can be searched without constraints
+func logistic(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func logit(p float64) float64 {
+	return math.Log(p / (1 - p))
+}
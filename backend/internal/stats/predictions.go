@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Step used when none is given: one record per day
+const defaultStep = 24 * time.Hour
+
+// FieldModel is a fitted univariate model for one field of models.Record
+type FieldModel interface {
+	Forecast(h int) []float64
+	ForecastVariance(h int) []float64
+	Simulate(h int, rng *rand.Rand) []float64
+}
+
+// ForecastOptions controls how predictions are generated.
+// The zero value produces the noise-free expected path.
+type ForecastOptions struct {
+	Intervals  int
+	Step       time.Duration // spacing between forecasted records, daily when zero
+	Stochastic bool          // add bootstrapped residual noise instead of returning the expected path
+	Seed       uint64        // seeds the noise so stochastic paths can be regenerated exactly
+
+	ConfidenceLevels []float64 // prediction intervals to attach to each record, e.g. 0.8 and 0.95
+}
+
+// Helper function to build the records that follow last from one model per field,
+// ordered spread, volume, bid price
+func predictRecords(last models.Record, step time.Duration, fields [3]FieldModel, opts ForecastOptions) []models.Record {
+	intervals := opts.Intervals
+	var paths [3][]float64
+	for i, field := range fields {
+		if opts.Stochastic {
+			// Independent stream per field so one field's draws don't shift another's
+			paths[i] = field.Simulate(intervals, rand.New(rand.NewPCG(opts.Seed, uint64(i))))
+		} else {
+			paths[i] = field.Forecast(intervals)
+		}
+	}
+	spreads, volumes, bidPrices := paths[0], paths[1], paths[2]
+
+	// Intervals are centred on the expected path, even for stochastic runs
+	var spreadBounds, volumeBounds, bidPriceBounds bounds
+	if len(opts.ConfidenceLevels) > 0 {
+		spreadBounds = newBounds(fields[0], intervals)
+		volumeBounds = newBounds(fields[1], intervals)
+		bidPriceBounds = newBounds(fields[2], intervals)
+	}
+
+	var predictions []models.Record
+	for i := 0; i < intervals; i++ {
+		record := models.Record{
+			AssetType:    last.AssetType,
+			Timestamp:    last.Timestamp.Add(time.Duration(i+1) * step),
+			BidAskSpread: math.Max(spreads[i], 0),
+			Volume:       math.Max(volumes[i], 0),
+			BidPrice:     math.Max(bidPrices[i], 0),
+		}
+		for _, level := range opts.ConfidenceLevels {
+			interval := models.PredictionInterval{Level: level}
+			interval.BidAskSpreadLower, interval.BidAskSpreadUpper = spreadBounds.at(i, level)
+			interval.VolumeLower, interval.VolumeUpper = volumeBounds.at(i, level)
+			interval.BidPriceLower, interval.BidPriceUpper = bidPriceBounds.at(i, level)
+			record.Intervals = append(record.Intervals, interval)
+		}
+		predictions = append(predictions, record)
+	}
+	return predictions
+}
+
+// bounds pairs a field's expected path with its forecast standard errors
+type bounds struct {
+	expected []float64
+	stdErr   []float64
+}
+
+func newBounds(model FieldModel, h int) bounds {
+	variance := model.ForecastVariance(h)
+	stdErr := make([]float64, h)
+	for i, v := range variance {
+		stdErr[i] = math.Sqrt(v)
+	}
+	return bounds{expected: model.Forecast(h), stdErr: stdErr}
+}
+
+// Helper function to get the lower and upper bound for step i, floored at 0
+// since spreads, volumes and prices can't go negative
+func (b bounds) at(i int, level float64) (float64, float64) {
+	margin := NormalQuantile(0.5+level/2) * b.stdErr[i]
+	return math.Max(b.expected[i]-margin, 0), math.Max(b.expected[i]+margin, 0)
+}
+
+// Helper function to extract a field from records
+func extractField(records []models.Record, selector func(models.Record) float64) []float64 {
+	var result []float64
+	for _, record := range records {
+		result = append(result, selector(record))
+	}
+	return result
+}
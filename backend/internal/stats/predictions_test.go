@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Helper function for 120 days of records with a weekly cycle and some irregular noise
+func testRecords() []models.Record {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]models.Record, 120)
+	for i := range records {
+		wobble := math.Sin(float64(i)*2*math.Pi/7) + 0.3*math.Sin(float64(i*i)*0.37)
+		records[i] = models.Record{
+			AssetType:    "TEST",
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			BidAskSpread: 0.5 + 0.05*wobble,
+			Volume:       1000 + 100*wobble,
+			BidPrice:     100 + 0.05*float64(i) + wobble,
+		}
+	}
+	return records
+}
+
+// Helper function to check two prediction runs match bit for bit
+func sameBits(a, b []models.Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Timestamp.Equal(b[i].Timestamp) ||
+			math.Float64bits(a[i].BidAskSpread) != math.Float64bits(b[i].BidAskSpread) ||
+			math.Float64bits(a[i].Volume) != math.Float64bits(b[i].Volume) ||
+			math.Float64bits(a[i].BidPrice) != math.Float64bits(b[i].BidPrice) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStochasticPredictionsRepeatForSameSeed(t *testing.T) {
+	opts := ForecastOptions{Intervals: 30, Stochastic: true, Seed: 42}
+	first, _ := GeneratePredictions(testRecords(), opts)
+	second, _ := GeneratePredictions(testRecords(), opts)
+	if !sameBits(first, second) {
+		t.Fatal("same seed gave different predictions")
+	}
+
+	opts.Seed = 43
+	other, _ := GeneratePredictions(testRecords(), opts)
+	if sameBits(first, other) {
+		t.Fatal("different seeds gave identical predictions")
+	}
+}
+
+func TestExpectedPredictionsHaveNoNoise(t *testing.T) {
+	model, err := FitRecords(testRecords(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	predictions := model.Predict(ForecastOptions{Intervals: 30, Seed: 42})
+	if len(predictions) != 30 {
+		t.Fatalf("got %d predictions, want 30", len(predictions))
+	}
+
+	// The seed only matters for stochastic runs, and the path is the models' own forecast
+	if other := model.Predict(ForecastOptions{Intervals: 30, Seed: 7}); !sameBits(predictions, other) {
+		t.Fatal("expected path depends on the seed")
+	}
+	spreads := model.BidAskSpread.Forecast(30)
+	volumes := model.Volume.Forecast(30)
+	bidPrices := model.BidPrice.Forecast(30)
+	for i, p := range predictions {
+		if p.BidAskSpread != math.Max(spreads[i], 0) || p.Volume != math.Max(volumes[i], 0) || p.BidPrice != math.Max(bidPrices[i], 0) {
+			t.Fatalf("prediction %d is off the expected path: %+v", i, p)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Longest season considered, keeps minute data from trying week-long cycles
+const maxSeasonLength = 1440
+
+// Calendar cycles liquidity tends to repeat over
+var seasonalCycles = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// Resample aggregates records into buckets of length step so the series has one
+// observation per forecast step. Within a bucket the spread is averaged, volume is
+// summed and the last bid price is kept. Buckets with no records are dropped rather
+// than filled, so market closures don't show up as zero-volume periods.
+func Resample(records []models.Record, step time.Duration) []models.Record {
+	if len(records) == 0 || step <= 0 {
+		return records
+	}
+
+	sorted := append([]models.Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var resampled []models.Record
+	var spreadSum float64
+	count := 0
+	flush := func() {
+		if count > 0 {
+			resampled[len(resampled)-1].BidAskSpread = spreadSum / float64(count)
+		}
+	}
+
+	for _, record := range sorted {
+		bucket := record.Timestamp.Truncate(step)
+		if len(resampled) == 0 || !resampled[len(resampled)-1].Timestamp.Equal(bucket) {
+			flush()
+			resampled = append(resampled, models.Record{
+				AssetType: record.AssetType,
+				Timestamp: bucket,
+			})
+			spreadSum, count = 0, 0
+		}
+		current := &resampled[len(resampled)-1]
+		spreadSum += record.BidAskSpread
+		count++
+		current.Volume += record.Volume
+		current.BidPrice = record.BidPrice
+	}
+	flush()
+
+	return resampled
+}
+
+// SeasonLengthsFor lists the season lengths worth trying for a series sampled every step
+func SeasonLengthsFor(step time.Duration) []int {
+	var lengths []int
+	if step == 24*time.Hour {
+		lengths = append(lengths, 5) // trading week on daily ETF data
+	}
+	for _, cycle := range seasonalCycles {
+		if step <= 0 || cycle%step != 0 {
+			continue
+		}
+		if m := int(cycle / step); m >= 2 && m <= maxSeasonLength {
+			lengths = append(lengths, m)
+		}
+	}
+	return lengths
+}
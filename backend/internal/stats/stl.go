@@ -0,0 +1,281 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// STL settings from Cleveland et al. (1990)
+const (
+	stlSeasonalSpan     = 7  // LOESS span across cycles of each subseries, odd and at least 7
+	stlInnerIterations  = 2  // passes of the seasonal and trend smoothers per outer loop
+	stlRobustIterations = 15 // outer loops reweighting outliers when robust
+)
+
+// STLDecomposition splits a series into trend + seasonal + residual
+type STLDecomposition struct {
+	SeasonLength     int       `json:"season_length"`
+	SeasonalStrength float64   `json:"seasonal_strength"` // 0 to 1, share of detrended variance the season explains
+	TrendStrength    float64   `json:"trend_strength"`    // 0 to 1, share of deseasonalised variance the trend explains
+	Trend            []float64 `json:"trend"`
+	Seasonal         []float64 `json:"seasonal"`
+	Residual         []float64 `json:"residual"`
+}
+
+// STL decomposes data with seasonal-trend decomposition using LOESS. With robust set,
+// outer iterations downweight large residuals so spikes land in the residual rather
+// than bending the trend or season.
+func STL(data []float64, seasonLength int, robust bool) (STLDecomposition, error) {
+	n := len(data)
+	if seasonLength < 2 {
+		return STLDecomposition{}, fmt.Errorf("season length must be at least 2")
+	}
+	if n < 2*seasonLength {
+		return STLDecomposition{}, fmt.Errorf("need at least two seasons (%d observations), got %d", 2*seasonLength, n)
+	}
+
+	// Spans of the low-pass and trend smoothers, as recommended in the paper
+	lowPassSpan := nextOdd(float64(seasonLength))
+	trendSpan := nextOdd(1.5 * float64(seasonLength) / (1 - 1.5/stlSeasonalSpan))
+
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	trend := make([]float64, n)
+	seasonal := make([]float64, n)
+
+	outer := 1
+	if robust {
+		outer += stlRobustIterations
+	}
+	for o := 0; o < outer; o++ {
+		for inner := 0; inner < stlInnerIterations; inner++ {
+			detrended := make([]float64, n)
+			for i := range data {
+				detrended[i] = data[i] - trend[i]
+			}
+
+			// Smooth each cycle-subseries, extended one cycle either side
+			cycles := make([]float64, n+2*seasonLength)
+			for k := 0; k < seasonLength; k++ {
+				var values, w []float64
+				for i := k; i < n; i += seasonLength {
+					values = append(values, detrended[i])
+					w = append(w, weights[i])
+				}
+				positions := make([]float64, len(values)+2)
+				for j := range positions {
+					positions[j] = float64(j - 1)
+				}
+				for j, v := range loess(values, w, stlSeasonalSpan, positions) {
+					cycles[k+j*seasonLength] = v
+				}
+			}
+
+			// Low-pass filter the cycles so the seasonal component carries no trend
+			lowPass := movingAverage(movingAverage(movingAverage(cycles, seasonLength), seasonLength), 3)
+			lowPass = loess(lowPass, nil, lowPassSpan, indexPositions(n))
+			for i := range seasonal {
+				seasonal[i] = cycles[seasonLength+i] - lowPass[i]
+			}
+
+			deseasonalised := make([]float64, n)
+			for i := range data {
+				deseasonalised[i] = data[i] - seasonal[i]
+			}
+			trend = loess(deseasonalised, weights, trendSpan, indexPositions(n))
+		}
+
+		if o < outer-1 {
+			weights = robustnessWeights(data, trend, seasonal)
+		}
+	}
+
+	residual := make([]float64, n)
+	detrended := make([]float64, n)
+	deseasonalised := make([]float64, n)
+	for i := range data {
+		residual[i] = data[i] - trend[i] - seasonal[i]
+		detrended[i] = seasonal[i] + residual[i]
+		deseasonalised[i] = trend[i] + residual[i]
+	}
+	return STLDecomposition{
+		SeasonLength:     seasonLength,
+		SeasonalStrength: strength(residual, detrended),
+		TrendStrength:    strength(residual, deseasonalised),
+		Trend:            trend,
+		Seasonal:         seasonal,
+		Residual:         residual,
+	}, nil
+}
+
+// DetectSeasonLength returns the candidate with the strongest autocorrelation, or 0
+// when none reaches 0.3. Candidates need at least minCycles full seasons in data. Each
+// candidate is scored on the series minus a moving average over one season, clipped at
+// five MADs, so neither a trend nor a single spike hides the season.
+func DetectSeasonLength(data []float64, candidates []int, minCycles int) (int, float64) {
+	best, bestACF := 0, minSeasonalACF
+	for _, length := range candidates {
+		if length < 2 || len(data) < minCycles*length {
+			continue
+		}
+		if acf := seasonalAutocorrelation(data, length); acf > bestACF {
+			best, bestACF = length, acf
+		}
+	}
+	if best == 0 {
+		return 0, 0
+	}
+	return best, bestACF
+}
+
+// AutoSTL detects the season length among candidates by autocorrelation, then
+// decomposes data with STL
+func AutoSTL(data []float64, candidates []int, robust bool) (STLDecomposition, error) {
+	seasonLength, _ := DetectSeasonLength(data, candidates, 3)
+	if seasonLength == 0 {
+		return STLDecomposition{}, fmt.Errorf("no seasonality detected among season lengths %v", candidates)
+	}
+	return STL(data, seasonLength, robust)
+}
+
+// Helper function for the lag-length autocorrelation after removing the trend with a
+// moving average over one season (which cancels the season itself) and clipping outliers
+func seasonalAutocorrelation(data []float64, length int) float64 {
+	average := movingAverage(data, length)
+	detrended := make([]float64, len(average))
+	for j, v := range average {
+		detrended[j] = data[j+length/2] - v
+	}
+
+	sorted := append([]float64(nil), detrended...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	deviations := make([]float64, len(sorted))
+	for i, v := range sorted {
+		deviations[i] = math.Abs(v - median)
+	}
+	sort.Float64s(deviations)
+	if limit := 5 * 1.4826 * deviations[len(deviations)/2]; limit > 0 {
+		for i, v := range detrended {
+			detrended[i] = math.Max(median-limit, math.Min(median+limit, v))
+		}
+	}
+	return Autocorrelation(detrended, length)
+}
+
+// Helper function for locally weighted linear regression of values (at positions
+// 0..n-1) with a tricube kernel over the nearest span points, evaluated at positions.
+// weights scales each point's influence and may be nil.
+func loess(values, weights []float64, span int, positions []float64) []float64 {
+	n := len(values)
+	out := make([]float64, len(positions))
+	window := min(span, n)
+	for p, x := range positions {
+		left := int(math.Round(x)) - window/2
+		left = max(0, min(left, n-window))
+		right := left + window - 1
+		bandwidth := math.Max(x-float64(left), float64(right)-x)
+		if span > n {
+			bandwidth += float64(span-n) / 2
+		}
+		bandwidth = math.Max(bandwidth, 1)
+
+		var sw, sx, sy, sxx, sxy float64
+		for i := left; i <= right; i++ {
+			u := math.Abs(float64(i)-x) / bandwidth
+			if u >= 1 {
+				continue
+			}
+			w := math.Pow(1-u*u*u, 3)
+			if weights != nil {
+				w *= weights[i]
+			}
+			xi := float64(i)
+			sw += w
+			sx += w * xi
+			sy += w * values[i]
+			sxx += w * xi * xi
+			sxy += w * xi * values[i]
+		}
+		if sw == 0 {
+			out[p] = mean(values[left : right+1])
+			continue
+		}
+		meanX, meanY := sx/sw, sy/sw
+		slope := 0.0
+		if spread := sxx/sw - meanX*meanX; spread > 1e-12 {
+			slope = (sxy/sw - meanX*meanY) / spread
+		}
+		out[p] = meanY + slope*(x-meanX)
+	}
+	return out
+}
+
+// Helper function for a trailing moving average, which shortens data by length-1
+func movingAverage(data []float64, length int) []float64 {
+	out := make([]float64, 0, len(data)-length+1)
+	sum := 0.0
+	for i, v := range data {
+		sum += v
+		if i >= length {
+			sum -= data[i-length]
+		}
+		if i >= length-1 {
+			out = append(out, sum/float64(length))
+		}
+	}
+	return out
+}
+
+// Helper function for bisquare weights on the residuals, scaled by six median absolute residuals
+func robustnessWeights(data, trend, seasonal []float64) []float64 {
+	abs := make([]float64, len(data))
+	for i := range data {
+		abs[i] = math.Abs(data[i] - trend[i] - seasonal[i])
+	}
+	sorted := append([]float64(nil), abs...)
+	sort.Float64s(sorted)
+	h := 6 * sorted[len(sorted)/2]
+
+	weights := make([]float64, len(data))
+	for i, r := range abs {
+		if h == 0 {
+			weights[i] = 1
+			continue
+		}
+		if u := r / h; u < 1 {
+			weights[i] = (1 - u*u) * (1 - u*u)
+		}
+	}
+	return weights
+}
+
+// Helper function for the strength of a component, 1 - var(residual)/var(component + residual)
+func strength(residual, combined []float64) float64 {
+	total := variance(combined)
+	if total == 0 {
+		return 0
+	}
+	return math.Max(0, 1-variance(residual)/total)
+}
+
+// Helper function to round up to the next odd integer
+func nextOdd(v float64) int {
+	n := int(math.Ceil(v))
+	if n%2 == 0 {
+		n++
+	}
+	return n
+}
+
+// Helper function for the positions 0..n-1
+func indexPositions(n int) []float64 {
+	positions := make([]float64, n)
+	for i := range positions {
+		positions[i] = float64(i)
+	}
+	return positions
+}
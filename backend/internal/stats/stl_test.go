@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Helper function for a linear trend plus a weekly cycle and a little noise
+func seasonalSeries(n int) []float64 {
+	rng := rand.New(rand.NewPCG(1, 1))
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 10 + 0.05*float64(i) + 2*math.Sin(2*math.Pi*float64(i)/7) + 0.1*rng.NormFloat64()
+	}
+	return data
+}
+
+func TestSTLReconstructsInput(t *testing.T) {
+	data := seasonalSeries(140)
+	for _, robust := range []bool{false, true} {
+		decomposition, err := STL(data, 7, robust)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range data {
+			sum := decomposition.Trend[i] + decomposition.Seasonal[i] + decomposition.Residual[i]
+			if math.Abs(sum-v) > 1e-9 {
+				t.Fatalf("robust=%v: trend + seasonal + residual = %v at %d, want %v", robust, sum, i, v)
+			}
+		}
+		if decomposition.SeasonalStrength < 0.9 {
+			t.Errorf("robust=%v: seasonal strength %.3f, want a strong season", robust, decomposition.SeasonalStrength)
+		}
+		// The seasonal component follows the sine away from the ends
+		for i := 14; i < 126; i++ {
+			if want := 2 * math.Sin(2*math.Pi*float64(i)/7); math.Abs(decomposition.Seasonal[i]-want) > 0.3 {
+				t.Fatalf("robust=%v: seasonal %.3f at %d, want about %.3f", robust, decomposition.Seasonal[i], i, want)
+			}
+		}
+	}
+}
+
+func TestRobustSTLLeavesSpikeInResidual(t *testing.T) {
+	data := seasonalSeries(140)
+	data[70] += 50
+	decomposition, err := STL(data, 7, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decomposition.Residual[70] < 45 {
+		t.Errorf("spike residual %.2f, want most of the 50 spike", decomposition.Residual[70])
+	}
+}
+
+func TestDetectSeasonLength(t *testing.T) {
+	data := seasonalSeries(140)
+	if length, _ := DetectSeasonLength(data, []int{5, 7, 12}, 3); length != 7 {
+		t.Errorf("detected season length %d, want 7", length)
+	}
+	if _, err := STL(data[:13], 7, false); err == nil {
+		t.Error("decomposed fewer than two seasons")
+	}
+}
@@ -0,0 +1,104 @@
+package stress
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+	"github.com/bedminer1/liquidity_tracker/internal/stats"
+)
+
+// Records before an episode (and at the end of the target's history) that its
+// normal state is measured over
+const DefaultReplayLookback = 30
+
+// ReplayStep is the source's state some periods into the episode, relative to its
+// state before the episode
+type ReplayStep struct {
+	Offset       int     `json:"offset"`
+	BidAskSpread float64 `json:"bid_ask_spread"` // multiple of the median spread before the episode
+	Volume       float64 `json:"volume"`         // multiple of the median volume before the episode
+	BidPrice     float64 `json:"bid_price"`      // multiple of the last price before the episode
+}
+
+// ReplayPath measures an episode in the source series as multiples of its state before
+// episodeStart. source must be resampled to the target's step and include up to lookback
+// records before the episode. Medians rather than the last record are used for spread
+// and volume so one quiet or busy period doesn't distort the whole path.
+func ReplayPath(source []models.Record, episodeStart time.Time, lookback int) ([]ReplayStep, error) {
+	if lookback <= 0 {
+		lookback = DefaultReplayLookback
+	}
+	var before, episode []models.Record
+	for _, record := range source {
+		if record.Timestamp.Before(episodeStart) {
+			before = append(before, record)
+		} else {
+			episode = append(episode, record)
+		}
+	}
+	if len(before) == 0 {
+		return nil, fmt.Errorf("need source records before the episode to measure it against")
+	}
+	if len(episode) == 0 {
+		return nil, fmt.Errorf("no source records in the episode")
+	}
+
+	spread, volume, price := normalState(before, lookback)
+	if spread <= 0 || volume <= 0 || price <= 0 {
+		return nil, fmt.Errorf("source spread, volume and price must be positive before the episode")
+	}
+	path := make([]ReplayStep, len(episode))
+	for i, record := range episode {
+		if record.BidPrice <= 0 {
+			return nil, fmt.Errorf("source bid price is %g at %s, can't replay it", record.BidPrice, record.Timestamp)
+		}
+		path[i] = ReplayStep{
+			Offset:       i + 1,
+			BidAskSpread: record.BidAskSpread / spread,
+			Volume:       record.Volume / volume,
+			BidPrice:     record.BidPrice / price,
+		}
+	}
+	return path, nil
+}
+
+// Replay transplants the path onto the end of history, scaling the target's own normal
+// state by each step's multiples. The replayed records follow history one step apart,
+// so they stand in for a forecast when assessed.
+func Replay(history []models.Record, path []ReplayStep, step time.Duration, lookback int) ([]models.Record, error) {
+	if lookback <= 0 {
+		lookback = DefaultReplayLookback
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no target records to replay onto")
+	}
+	spread, volume, price := normalState(history, lookback)
+	last := history[len(history)-1]
+
+	replayed := make([]models.Record, len(path))
+	for i, s := range path {
+		replayed[i] = models.Record{
+			AssetType:    last.AssetType,
+			Timestamp:    last.Timestamp.Add(time.Duration(s.Offset) * step),
+			BidAskSpread: spread * s.BidAskSpread,
+			Volume:       volume * s.Volume,
+			BidPrice:     price * s.BidPrice,
+		}
+	}
+	return replayed, nil
+}
+
+// Helper function for the median spread and volume of the last lookback records and the last price
+func normalState(records []models.Record, lookback int) (spread, volume, price float64) {
+	if len(records) > lookback {
+		records = records[len(records)-lookback:]
+	}
+	spreads := make([]float64, len(records))
+	volumes := make([]float64, len(records))
+	for i, record := range records {
+		spreads[i] = record.BidAskSpread
+		volumes[i] = record.Volume
+	}
+	return stats.Quantile(spreads, 0.5), stats.Quantile(volumes, 0.5), records[len(records)-1].BidPrice
+}
@@ -0,0 +1,85 @@
+package stress
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+func TestReplayTransplantsRelativeChanges(t *testing.T) {
+	// 30 calm days, then the spread widens while volume and price fall
+	source := dailyRecords(32, "Crypto_LUNA")
+	source[30].BidAskSpread, source[30].Volume, source[30].BidPrice = 3, 500, 50
+	source[31].BidAskSpread, source[31].Volume, source[31].BidPrice = 5, 250, 20
+	episodeStart := start.Add(30 * day)
+
+	path, err := ReplayPath(source, episodeStart, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := []ReplayStep{{1, 3, 0.5, 0.5}, {2, 5, 0.25, 0.2}}
+	if len(path) != len(wantPath) {
+		t.Fatalf("path has %d steps, want %d", len(path), len(wantPath))
+	}
+	for i, step := range path {
+		if step != wantPath[i] {
+			t.Errorf("step %d is %+v, want %+v", i, step, wantPath[i])
+		}
+	}
+
+	history := dailyRecords(10, "Equity_AAPL")
+	for i := range history {
+		history[i].BidAskSpread, history[i].Volume, history[i].BidPrice = 0.2, 4000, 10
+	}
+	replayed, err := Replay(history, path, day, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := history[len(history)-1].Timestamp
+	want := []models.Record{
+		{AssetType: "Equity_AAPL", Timestamp: last.Add(day), BidAskSpread: 0.6, Volume: 2000, BidPrice: 5},
+		{AssetType: "Equity_AAPL", Timestamp: last.Add(2 * day), BidAskSpread: 1, Volume: 1000, BidPrice: 2},
+	}
+	for i, record := range replayed {
+		if record.AssetType != want[i].AssetType || !record.Timestamp.Equal(want[i].Timestamp) ||
+			math.Abs(record.BidAskSpread-want[i].BidAskSpread) > 1e-12 ||
+			math.Abs(record.Volume-want[i].Volume) > 1e-9 || math.Abs(record.BidPrice-want[i].BidPrice) > 1e-12 {
+			t.Errorf("replayed %d is %+v, want %+v", i, record, want[i])
+		}
+	}
+}
+
+func TestReplayPathUsesLookbackMedians(t *testing.T) {
+	source := dailyRecords(11, "Crypto_LUNA")
+	// A spike outside the lookback and a quiet day inside it shouldn't move the baseline
+	source[0].BidAskSpread = 100
+	source[8].Volume = 1
+	source[10].BidAskSpread = 2
+	path, err := ReplayPath(source, start.Add(10*day), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path[0].BidAskSpread != 2 || path[0].Volume != 1 {
+		t.Errorf("step %+v, want spread doubled and volume unchanged", path[0])
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	source := dailyRecords(5, "Crypto_LUNA")
+	if _, err := ReplayPath(source, start, 0); err == nil {
+		t.Error("measured an episode with nothing before it")
+	}
+	if _, err := ReplayPath(source, start.Add(10*day), 0); err == nil {
+		t.Error("measured an episode with no records in it")
+	}
+	crashed := dailyRecords(5, "Crypto_LUNA")
+	crashed[4].BidPrice = 0
+	if _, err := ReplayPath(crashed, start.Add(3*day), 0); err == nil {
+		t.Error("replayed a zero price")
+	}
+	if _, err := Replay(nil, []ReplayStep{{1, 2, 1, 1}}, time.Hour, 0); err == nil {
+		t.Error("replayed onto an empty history")
+	}
+}
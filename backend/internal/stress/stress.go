@@ -0,0 +1,153 @@
+package stress
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+// Fields a shock can change
+const (
+	FieldBidAskSpread = "bid_ask_spread"
+	FieldVolume       = "volume"
+	FieldBidPrice     = "bid_price"
+)
+
+// How a shock changes a field
+const (
+	OperationMultiply = "multiply"
+	OperationAdd      = "add"
+)
+
+const day = 24 * time.Hour
+
+// Comparison summarises how far a scenario moves the liquidity report
+type Comparison struct {
+	BaselineScore      float64 `json:"baseline_score"`
+	StressedScore      float64 `json:"stressed_score"`
+	ScoreChange        float64 `json:"score_change"`
+	BaselineLevel      string  `json:"baseline_level"`
+	StressedLevel      string  `json:"stressed_level"`
+	HighRiskChange     int     `json:"high_risk_change"`
+	ModerateRiskChange int     `json:"moderate_risk_change"`
+	LVaRChange         float64 `json:"lvar_change"` // historical-simulation LVaR, as a fraction of position value
+}
+
+// Validate checks every shock names a known field and operation and has a sensible window
+func Validate(scenario models.Scenario) error {
+	if scenario.Name == "" {
+		return fmt.Errorf("scenario needs a 'name'")
+	}
+	if len(scenario.Shocks) == 0 {
+		return fmt.Errorf("scenario needs at least one shock")
+	}
+	for i, shock := range scenario.Shocks {
+		switch shock.Field {
+		case FieldBidAskSpread, FieldVolume, FieldBidPrice:
+		default:
+			return fmt.Errorf("shock %d: unknown field %q, use %s, %s or %s", i, shock.Field, FieldBidAskSpread, FieldVolume, FieldBidPrice)
+		}
+		switch shock.Operation {
+		case OperationMultiply:
+			if shock.Value < 0 {
+				return fmt.Errorf("shock %d: multiplier can't be negative", i)
+			}
+			if shock.Field == FieldBidPrice && shock.Value == 0 {
+				return fmt.Errorf("shock %d: a bid_price multiplier of 0 leaves nothing to measure spreads against", i)
+			}
+		case OperationAdd:
+			if shock.Field == FieldBidPrice && shock.Value < 0 {
+				return fmt.Errorf("shock %d: a negative bid_price shift can take the price below zero, use a multiplier for price falls", i)
+			}
+		default:
+			return fmt.Errorf("shock %d: unknown operation %q, use %s or %s", i, shock.Operation, OperationMultiply, OperationAdd)
+		}
+		if shock.StartDay < 0 || shock.Days < 0 {
+			return fmt.Errorf("shock %d: start_day and days can't be negative", i)
+		}
+	}
+	return nil
+}
+
+// Applies reports whether the shock covers the asset
+func Applies(shock models.Shock, assetType string) bool {
+	return strings.HasPrefix(assetType, shock.Assets)
+}
+
+// Apply returns a copy of records with the scenario's shocks applied, counting shock
+// windows from start. Shocks on the same field compound in the order they are listed,
+// and prediction interval bounds move with the point estimate. Spreads are measured
+// against the bid price, so a scenario that takes the price to zero or below is an error.
+func Apply(records []models.Record, scenario models.Scenario, start time.Time) ([]models.Record, error) {
+	shocked := make([]models.Record, len(records))
+	for i, record := range records {
+		record.Intervals = append([]models.PredictionInterval(nil), record.Intervals...)
+		for _, shock := range scenario.Shocks {
+			if !Applies(shock, record.AssetType) || !inWindow(shock, record.Timestamp, start) {
+				continue
+			}
+			switch shock.Field {
+			case FieldBidAskSpread:
+				record.BidAskSpread = shockValue(shock, record.BidAskSpread)
+			case FieldVolume:
+				record.Volume = shockValue(shock, record.Volume)
+			case FieldBidPrice:
+				record.BidPrice = shockValue(shock, record.BidPrice)
+			}
+			for j := range record.Intervals {
+				interval := &record.Intervals[j]
+				switch shock.Field {
+				case FieldBidAskSpread:
+					interval.BidAskSpreadLower = shockValue(shock, interval.BidAskSpreadLower)
+					interval.BidAskSpreadUpper = shockValue(shock, interval.BidAskSpreadUpper)
+				case FieldVolume:
+					interval.VolumeLower = shockValue(shock, interval.VolumeLower)
+					interval.VolumeUpper = shockValue(shock, interval.VolumeUpper)
+				case FieldBidPrice:
+					interval.BidPriceLower = shockValue(shock, interval.BidPriceLower)
+					interval.BidPriceUpper = shockValue(shock, interval.BidPriceUpper)
+				}
+			}
+		}
+		if record.BidPrice <= 0 {
+			return nil, fmt.Errorf("scenario %q takes the bid price of %s to %g at %s", scenario.Name, record.AssetType, record.BidPrice, record.Timestamp)
+		}
+		shocked[i] = record
+	}
+	return shocked, nil
+}
+
+// Compare lines up the headline figures of a baseline and stressed report
+func Compare(baseline, stressed models.LiquidityReport) Comparison {
+	return Comparison{
+		BaselineScore:      baseline.Score.Score,
+		StressedScore:      stressed.Score.Score,
+		ScoreChange:        stressed.Score.Score - baseline.Score.Score,
+		BaselineLevel:      baseline.Score.Level,
+		StressedLevel:      stressed.Score.Level,
+		HighRiskChange:     stressed.HighRiskCount - baseline.HighRiskCount,
+		ModerateRiskChange: stressed.ModerateRiskCount - baseline.ModerateRiskCount,
+		LVaRChange:         stressed.LVaR.Historical.LVaR - baseline.LVaR.Historical.LVaR,
+	}
+}
+
+// Helper function for whether t falls in the shock's window, which is open-ended when Days is 0
+func inWindow(shock models.Shock, t, start time.Time) bool {
+	from := start.Add(time.Duration(shock.StartDay * float64(day)))
+	if t.Before(from) {
+		return false
+	}
+	return shock.Days == 0 || t.Before(from.Add(time.Duration(shock.Days*float64(day))))
+}
+
+// Helper function for a shocked value. Additive shocks are floored at zero so they can't
+// produce negative spreads, volumes or interval bounds; Apply rejects a zero point price.
+func shockValue(shock models.Shock, value float64) float64 {
+	if shock.Operation == OperationMultiply {
+		return value * shock.Value
+	}
+	return math.Max(0, value+shock.Value)
+}
@@ -0,0 +1,120 @@
+package stress
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bedminer1/liquidity_tracker/internal/models"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Helper function for n daily records of each asset from start
+func dailyRecords(n int, assets ...string) []models.Record {
+	var records []models.Record
+	for _, asset := range assets {
+		for i := 0; i < n; i++ {
+			records = append(records, models.Record{
+				AssetType:    asset,
+				Timestamp:    start.Add(time.Duration(i) * day),
+				BidPrice:     100,
+				BidAskSpread: 1,
+				Volume:       1000,
+			})
+		}
+	}
+	return records
+}
+
+func TestValidate(t *testing.T) {
+	shock := func(field, operation string, value float64) models.Scenario {
+		return models.Scenario{Name: "test", Shocks: []models.Shock{{Field: field, Operation: operation, Value: value}}}
+	}
+	tests := []struct {
+		name     string
+		scenario models.Scenario
+		valid    bool
+	}{
+		{"spread doubles", shock(FieldBidAskSpread, OperationMultiply, 2), true},
+		{"volume dries up", shock(FieldVolume, OperationMultiply, 0), true},
+		{"price rises", shock(FieldBidPrice, OperationAdd, 5), true},
+		{"no name", models.Scenario{Shocks: shock(FieldVolume, OperationMultiply, 1).Shocks}, false},
+		{"no shocks", models.Scenario{Name: "test"}, false},
+		{"unknown field", shock("ask_price", OperationMultiply, 2), false},
+		{"unknown operation", shock(FieldVolume, "divide", 2), false},
+		{"negative multiplier", shock(FieldVolume, OperationMultiply, -1), false},
+		{"zero price", shock(FieldBidPrice, OperationMultiply, 0), false},
+		{"price falls by subtraction", shock(FieldBidPrice, OperationAdd, -5), false},
+		{"negative window", models.Scenario{Name: "test", Shocks: []models.Shock{{Field: FieldVolume, Operation: OperationMultiply, Value: 1, Days: -1}}}, false},
+	}
+	for _, test := range tests {
+		if err := Validate(test.scenario); (err == nil) != test.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestApplyWithinWindow(t *testing.T) {
+	scenario := models.Scenario{Name: "crunch", Shocks: []models.Shock{
+		{Assets: "Crypto_", Field: FieldBidAskSpread, Operation: OperationMultiply, Value: 3, StartDay: 2, Days: 3},
+		{Field: FieldVolume, Operation: OperationAdd, Value: -1500, StartDay: 4},
+	}}
+	records := dailyRecords(10, "Crypto_BTC", "Equity_AAPL")
+	shocked, err := Apply(records, scenario, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, record := range shocked {
+		offset := int(record.Timestamp.Sub(start) / day)
+		wantSpread, wantVolume := 1.0, 1000.0
+		if record.AssetType == "Crypto_BTC" && offset >= 2 && offset < 5 {
+			wantSpread = 3
+		}
+		if offset >= 4 {
+			wantVolume = 0 // floored rather than negative
+		}
+		if record.BidAskSpread != wantSpread || record.Volume != wantVolume {
+			t.Errorf("%s day %d: spread %v volume %v, want %v and %v", record.AssetType, offset, record.BidAskSpread, record.Volume, wantSpread, wantVolume)
+		}
+		if records[i].BidAskSpread != 1 || records[i].Volume != 1000 {
+			t.Fatalf("Apply changed its input at %d", i)
+		}
+	}
+}
+
+func TestApplyMovesIntervals(t *testing.T) {
+	records := dailyRecords(1, "Crypto_BTC")
+	records[0].Intervals = []models.PredictionInterval{{Level: 0.9, BidAskSpreadLower: 0.5, BidAskSpreadUpper: 2}}
+	scenario := models.Scenario{Name: "wide", Shocks: []models.Shock{{Field: FieldBidAskSpread, Operation: OperationMultiply, Value: 2}}}
+	shocked, err := Apply(records, scenario, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval := shocked[0].Intervals[0]; interval.BidAskSpreadLower != 1 || interval.BidAskSpreadUpper != 4 {
+		t.Errorf("interval %+v, want bounds doubled with the spread", interval)
+	}
+	if records[0].Intervals[0].BidAskSpreadUpper != 2 {
+		t.Error("Apply changed the input's intervals")
+	}
+}
+
+func TestApplyRejectsZeroPrice(t *testing.T) {
+	// Validate rejects this, Apply must still not hand back unmeasurable spreads
+	scenario := models.Scenario{Name: "wipeout", Shocks: []models.Shock{{Field: FieldBidPrice, Operation: OperationMultiply, Value: 0, StartDay: 1}}}
+	if _, err := Apply(dailyRecords(3, "Crypto_BTC"), scenario, start); err == nil {
+		t.Error("applied a scenario that takes the price to zero")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := models.LiquidityReport{Score: models.RiskScore{Score: 30, Level: "moderate"}, HighRiskCount: 1, ModerateRiskCount: 4}
+	stressed := models.LiquidityReport{Score: models.RiskScore{Score: 75, Level: "high"}, HighRiskCount: 6, ModerateRiskCount: 2}
+	baseline.LVaR.Historical.LVaR, stressed.LVaR.Historical.LVaR = 0.01, 0.04
+
+	comparison := Compare(baseline, stressed)
+	if comparison.ScoreChange != 45 || comparison.HighRiskChange != 5 || comparison.ModerateRiskChange != -2 ||
+		comparison.StressedLevel != "high" || math.Abs(comparison.LVaRChange-0.03) > 1e-12 {
+		t.Errorf("comparison %+v", comparison)
+	}
+}